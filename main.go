@@ -5,7 +5,11 @@ import (
 
 	"github.com/urfave/cli/v2"
 
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
+	"github.com/starryck/strk-tc-x-lib-go/source/entry/xbconfig"
 	"github.com/starryck/strk-tc-x-lib-go/source/entry/xbinfo"
+	"github.com/starryck/strk-tc-x-lib-go/source/entry/xbmessage"
+	"github.com/starryck/strk-tc-x-lib-go/source/entry/xbprecfg"
 	_ "github.com/starryck/strk-tc-x-lib-go/source/entry/xbpreset"
 	"github.com/starryck/strk-tc-x-lib-go/source/entry/xbscript"
 )
@@ -27,6 +31,9 @@ func init() {
 				Email: "gordon.lai@starryck.com",
 			},
 		},
+		Before: func(ctx *cli.Context) error {
+			return exitOnError(xbprecfg.ApplyFlags(ctx))
+		},
 		Action: func(ctx *cli.Context) error {
 			cli.ShowAppHelp(ctx)
 			return nil
@@ -37,6 +44,7 @@ func init() {
 				Usage:     "Present service information",
 				HelpName:  "show-info",
 				ArgsUsage: "[arguments...]",
+				Before:    validateConfig,
 				Action: func(ctx *cli.Context) error {
 					return xbinfo.Execute()
 				},
@@ -45,15 +53,123 @@ func init() {
 				Name:      "run-script",
 				Usage:     "Perform a script",
 				HelpName:  "run-script",
-				ArgsUsage: "[arguments...]",
+				ArgsUsage: "<script> [arguments...]",
+				Before:    validateConfig,
 				Action: func(ctx *cli.Context) error {
-					return xbscript.Execute()
+					return xbscript.Execute(ctx)
+				},
+				Subcommands: xbscript.MakeCommands(),
+			},
+			&cli.Command{
+				Name:     "config",
+				Usage:    "Inspect service configuration",
+				HelpName: "config",
+				Subcommands: []*cli.Command{
+					&cli.Command{
+						Name:     "dump",
+						Usage:    "Print the effective configuration with secrets redacted",
+						HelpName: "config dump",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "format",
+								Usage: "Output format: json, yaml or env",
+								Value: xbconfig.FormatJSON,
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							return exitOnError(xbconfig.ExecuteDump(ctx.String("format")))
+						},
+					},
+					&cli.Command{
+						Name:     "validate",
+						Usage:    "Validate the configuration without starting anything",
+						HelpName: "config validate",
+						Action: func(ctx *cli.Context) error {
+							return exitOnError(xbconfig.ExecuteValidate())
+						},
+					},
+					&cli.Command{
+						Name:      "diff",
+						Usage:     "Compare the configuration with another environment file",
+						HelpName:  "config diff",
+						ArgsUsage: "<env-file>",
+						Action: func(ctx *cli.Context) error {
+							if ctx.NArg() != 1 {
+								return cli.Exit("An environment file is required.", 1)
+							}
+							return exitOnError(xbconfig.ExecuteDiff(ctx.Args().First()))
+						},
+					},
+					&cli.Command{
+						Name:     "template",
+						Usage:    "Generate a commented .env template",
+						HelpName: "config template",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "output",
+								Usage: "Write the template to a file instead of stdout",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							return exitOnError(xbconfig.ExecuteTemplate(ctx.String("output")))
+						},
+					},
+				},
+			},
+			&cli.Command{
+				Name:     "messages",
+				Usage:    "Inspect registered meta messages",
+				HelpName: "messages",
+				Subcommands: []*cli.Command{
+					&cli.Command{
+						Name:     "export",
+						Usage:    "Print the message catalogue",
+						HelpName: "messages export",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "format",
+								Usage: "Output format: json, markdown or openapi",
+								Value: xbmessage.FormatJSON,
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							return exitOnError(xbmessage.ExecuteExport(ctx.String("format")))
+						},
+					},
+					&cli.Command{
+						Name:     "check",
+						Usage:    "Check that severity codes agree with HTTP codes",
+						HelpName: "messages check",
+						Action: func(ctx *cli.Context) error {
+							return exitOnError(xbmessage.ExecuteCheck())
+						},
+					},
 				},
 			},
 		},
 	}
 }
 
+func validateConfig(ctx *cli.Context) error {
+	if err := xbprecfg.ValidateConfig(xbcfg.GetConfig()); err != nil {
+		return exitOnError(err)
+	}
+	return nil
+}
+
+func exitOnError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if cerr, ok := err.(*xbprecfg.ConfigError); ok {
+		return cli.Exit(cerr.Report(), 1)
+	}
+	return cli.Exit(err.Error(), 1)
+}
+
+// The flags are made in `main`, so that an extended config chosen in any `init`
+// is already in use.
 func main() {
+	app.Flags = xbprecfg.MakeFlags()
 	app.Run(os.Args)
 }
@@ -1,6 +1,21 @@
 package xbcfg
 
-var mConfig Config
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	mConfigHolder  atomic.Pointer[configHolder]
+	mConfigLoader  func() Config
+	mConfigOnce    sync.Once
+	mWatchers      []Watcher
+	mWatchersMutex sync.Mutex
+)
+
+var mModuleMap = map[string]bool{}
 
 type Config interface {
 	GetBasePath() string
@@ -15,6 +30,7 @@ type Config interface {
 	GetServiceVersion() string
 	GetServiceEnvironment() string
 	GetServiceLogLevel() string
+	GetServiceErrorStack() bool
 	GetServiceTesting() bool
 	GetServiceDebugging() bool
 	GetServiceDeveloping() bool
@@ -26,15 +42,114 @@ type Config interface {
 	GetPostgresPassword() string
 }
 
+type configHolder struct {
+	config Config
+}
+
 func GetConfig() Config {
-	if mConfig == nil {
+	config, ok := LookupConfig()
+	if !ok && mConfigLoader != nil {
+		mConfigOnce.Do(func() {
+			if _, ok := LookupConfig(); !ok {
+				SetConfig(mConfigLoader())
+			}
+		})
+		config, ok = LookupConfig()
+	}
+	if !ok {
 		panic("Config hasn't been created.")
 	}
-	return mConfig
+	return config
 }
 
+// LookupConfig returns the config only when it has been set, without loading
+// it through the loader.
+func LookupConfig() (Config, bool) {
+	holder := mConfigHolder.Load()
+	if holder == nil {
+		return nil, false
+	}
+	return holder.config, true
+}
+
+// SetConfigLoader defers creating the config until it is first needed, i.e.
+// after every package has been initialized, unless it is set before then.
+func SetConfigLoader(loader func() Config) {
+	mConfigLoader = loader
+}
+
+// SetConfig atomically swaps the config and notifies the watchers when an
+// existing config gets replaced.
 func SetConfig(config Config) {
-	mConfig = config
+	holder := mConfigHolder.Swap(&configHolder{config: config})
+	if holder != nil {
+		notifyWatchers(holder.config, config)
+	}
+}
+
+type SectionConfig interface {
+	GetSection(sectionType reflect.Type) (any, bool)
+}
+
+// Section returns a custom section of an extended config by its type, e.g.
+// `xbcfg.Section[RedisConfig]()`.
+func Section[T any]() T {
+	section, ok := LookupSection[T]()
+	if !ok {
+		panic(fmt.Sprintf("Config section `%s` hasn't been registered.", reflect.TypeFor[T]()))
+	}
+	return section
+}
+
+func LookupSection[T any]() (T, bool) {
+	return LookupSectionOf[T](GetConfig())
+}
+
+func LookupSectionOf[T any](config Config) (T, bool) {
+	if config, ok := config.(SectionConfig); ok {
+		if section, ok := config.GetSection(reflect.TypeFor[T]()); ok {
+			return section.(T), true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+type Watcher = func(prev, next Config)
+
+func Subscribe(watcher Watcher) {
+	mWatchersMutex.Lock()
+	defer mWatchersMutex.Unlock()
+	mWatchers = append(mWatchers, watcher)
+}
+
+// Watch subscribes to a single field and is only called when it changes, e.g.
+// `xbcfg.Watch(xbcfg.Config.GetServiceLogLevel, func(prev, next string) {...})`.
+func Watch[T comparable](getter func(Config) T, operate func(prev, next T)) {
+	Subscribe(func(prev, next Config) {
+		if prevValue, nextValue := getter(prev), getter(next); prevValue != nextValue {
+			operate(prevValue, nextValue)
+		}
+	})
+}
+
+func notifyWatchers(prev, next Config) {
+	mWatchersMutex.Lock()
+	watchers := append([]Watcher{}, mWatchers...)
+	mWatchersMutex.Unlock()
+	for _, watcher := range watchers {
+		watcher(prev, next)
+	}
+}
+
+// UseModule declares that a module relying on the config is linked, so that
+// the config can require the module settings.
+func UseModule(name string) {
+	mModuleMap[name] = true
+}
+
+func HasModule(name string) bool {
+	return mModuleMap[name]
 }
 
 // Base definition
@@ -85,6 +200,10 @@ func GetServiceLogLevel() string {
 	return GetConfig().GetServiceLogLevel()
 }
 
+func GetServiceErrorStack() bool {
+	return GetConfig().GetServiceErrorStack()
+}
+
 func GetServiceTesting() bool {
 	return GetConfig().GetServiceTesting()
 }
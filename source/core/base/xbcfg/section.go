@@ -0,0 +1,78 @@
+package xbcfg
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbtype"
+)
+
+var mDefaultSections sync.Map
+
+// LogConfig holds the logging options beyond `GetServiceLogLevel`, which are
+// built as a section of `xbprecfg.Config`.
+type LogConfig struct {
+	PackageLevels  string        `json:"packageLevels" env:"SRV_LOG_PACKAGE_LEVELS" validate:"packageLevels"`
+	DebugSecret    xbtype.Secret `json:"debugSecret" env:"SRV_LOG_DEBUG_SECRET"`
+	Format         string        `json:"format" env:"SRV_LOG_FORMAT" envDefault:"json" validate:"enum:json,text,logfmt,ecs,gcp"`
+	Outputs        string        `json:"outputs" env:"SRV_LOG_OUTPUTS" envDefault:"stdout" validate:"logOutputs"`
+	SampleFirst    int           `json:"sampleFirst" env:"SRV_LOG_SAMPLE_FIRST" envDefault:"0" validate:"range:0,1000000"`
+	SampleEvery    int           `json:"sampleEvery" env:"SRV_LOG_SAMPLE_EVERY" envDefault:"100" validate:"range:0,1000000"`
+	SampleInterval time.Duration `json:"sampleInterval" env:"SRV_LOG_SAMPLE_INTERVAL" envDefault:"1s"`
+	SampleLevel    string        `json:"sampleLevel" env:"SRV_LOG_SAMPLE_LEVEL" envDefault:"info" validate:"logLevel"`
+	SampleLevels   string        `json:"sampleLevels" env:"SRV_LOG_SAMPLE_LEVELS" validate:"sampleLevels"`
+	RateLimit      int           `json:"rateLimit" env:"SRV_LOG_RATE_LIMIT" envDefault:"0" validate:"range:0,1000000"`
+	RateBurst      int           `json:"rateBurst" env:"SRV_LOG_RATE_BURST" envDefault:"0" validate:"range:0,1000000"`
+	DedupInterval  time.Duration `json:"dedupInterval" env:"SRV_LOG_DEDUP_INTERVAL" envDefault:"0s"`
+	Redaction      string        `json:"redaction" env:"SRV_LOG_REDACTION" envDefault:"rules" validate:"enum:none,rules,strict"`
+	RedactFields   string        `json:"redactFields" env:"SRV_LOG_REDACT_FIELDS"`
+	RedactPaths    string        `json:"redactPaths" env:"SRV_LOG_REDACT_PATHS"`
+	RedactAllows   string        `json:"redactAllows" env:"SRV_LOG_REDACT_ALLOWS"`
+	RedactPatterns string        `json:"redactPatterns" env:"SRV_LOG_REDACT_PATTERNS"`
+	Async          bool          `json:"async" env:"SRV_LOG_ASYNC" envDefault:"false"`
+	AsyncSize      int           `json:"asyncSize" env:"SRV_LOG_ASYNC_SIZE" envDefault:"4096" validate:"range:1,1000000"`
+	AsyncPolicy    string        `json:"asyncPolicy" env:"SRV_LOG_ASYNC_POLICY" envDefault:"drop" validate:"enum:drop,block"`
+}
+
+// HTTPConfig holds the options of the RESTful responses, which is built as a
+// section of `xbprecfg.Config`.
+type HTTPConfig struct {
+	Locale          string `json:"locale" env:"SRV_LOCALE" envDefault:"en" validate:"locale"`
+	RequestIDHeader string `json:"requestIDHeader" env:"SRV_REQUEST_ID_HEADER" envDefault:"X-Request-Id" validate:"required"`
+	RequestIDFormat string `json:"requestIDFormat" env:"SRV_REQUEST_ID_FORMAT" envDefault:"ksuid" validate:"enum:ksuid,xid"`
+	RequestIDTrust  bool   `json:"requestIDTrust" env:"SRV_REQUEST_ID_TRUST" envDefault:"false"`
+}
+
+func GetLogConfig() LogConfig {
+	return SectionOf[LogConfig](GetConfig())
+}
+
+func GetHTTPConfig() HTTPConfig {
+	return SectionOf[HTTPConfig](GetConfig())
+}
+
+// SectionOf returns a section of the config, or its defaults when the config
+// doesn't build it, e.g. a hand-written one, so that the library options don't
+// have to be implemented by every config.
+func SectionOf[T any](config Config) T {
+	if section, ok := LookupSectionOf[T](config); ok {
+		return section
+	}
+	return getDefaultSection[T]()
+}
+
+func getDefaultSection[T any]() T {
+	sectionType := reflect.TypeFor[T]()
+	if section, ok := mDefaultSections.Load(sectionType); ok {
+		return section.(T)
+	}
+	var section T
+	if err := env.ParseWithOptions(&section, env.Options{Environment: map[string]string{}}); err != nil {
+		panic(err)
+	}
+	mDefaultSections.Store(sectionType, section)
+	return section
+}
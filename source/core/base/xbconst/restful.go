@@ -2,10 +2,16 @@ package xbconst
 
 const (
 	ContextFlowMap        = "#flow_map"
+	ContextResponseFormat = "#response_format"
 	FlowKeyFlowID         = "#flow_id"
 	FlowKeyFlowTrails     = "#flow_trails"
 	FlowKeyFlowError      = "#flow_error"
 	FlowKeyFlowOutcome    = "#flow_outcome"
+	FlowKeyFlowSpan       = "#flow_span"
+	FlowKeyFlowParent     = "#flow_parent"
+	FlowKeyFlowLevel      = "#flow_level"
+	FlowKeyFlowLocales    = "#flow_locales"
+	FlowKeyRequestID      = "#request_id"
 	FlowKeyRequestParams  = "#request_params"
 	FlowKeyRequestQueries = "#request_queries"
 	FlowKeyRequestHeaders = "#request_headers"
@@ -13,9 +19,14 @@ const (
 	FlowKeyRequestData    = "#request_data"
 	FlowKeyRecordFields   = "#record_fields"
 
-	HeaderAuthorization = "Authorization"
-	HeaderRealIP        = "X-Real-Ip"
-	HeaderForwardedFor  = "X-Forwarded-For"
+	HeaderAuthorization   = "Authorization"
+	HeaderRealIP          = "X-Real-Ip"
+	HeaderForwardedFor    = "X-Forwarded-For"
+	HeaderLogDebug        = "X-Log-Debug"
+	HeaderAcceptLanguage  = "Accept-Language"
+	HeaderContentLanguage = "Content-Language"
+	HeaderContentType     = "Content-Type"
+	HeaderRetryAfter      = "Retry-After"
 
 	HeaderKongRequestID        = "Kong-Request-Id"
 	HeaderKongConsumerCustomID = "X-Consumer-Custom-Id"
@@ -8,3 +8,7 @@ const (
 	EnvironmentStage = "stage"
 	EnvironmentProd  = "prod"
 )
+
+const (
+	ModulePostgres = "postgres"
+)
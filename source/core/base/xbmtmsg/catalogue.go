@@ -0,0 +1,86 @@
+package xbmtmsg
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// MetaMessageEntry describes a registered meta message with its raw texts,
+// i.e. the format strings before the arguments apply.
+type MetaMessageEntry struct {
+	Code     string `json:"code"`
+	OutCode  string `json:"outCode"`
+	HTTPCode int    `json:"httpCode"`
+	OutText  string `json:"outText"`
+	LogText  string `json:"logText"`
+	Package  string `json:"package"`
+}
+
+// ListMetaMessages lists the registered meta messages sorted by code. Only the
+// messages of the packages linked into the binary are registered.
+func ListMetaMessages() []*MetaMessage {
+	codes := slices.Sorted(maps.Keys(metaMessageCatalogue))
+	metaMessages := make([]*MetaMessage, len(codes))
+	for i, code := range codes {
+		metaMessages[i] = metaMessageCatalogue[code]
+	}
+	return metaMessages
+}
+
+func LookupMetaMessage(code string) (*MetaMessage, bool) {
+	metaMessage, ok := metaMessageCatalogue[code]
+	return metaMessage, ok
+}
+
+func ListMetaMessageEntries() []*MetaMessageEntry {
+	metaMessages := ListMetaMessages()
+	entries := make([]*MetaMessageEntry, len(metaMessages))
+	for i, metaMessage := range metaMessages {
+		entries[i] = metaMessage.GetEntry()
+	}
+	return entries
+}
+
+func (metaMessage *MetaMessage) GetEntry() *MetaMessageEntry {
+	return &MetaMessageEntry{
+		Code:     metaMessage.code,
+		OutCode:  metaMessage.GetOutCode(),
+		HTTPCode: metaMessage.httpCode,
+		OutText:  metaMessage.outText,
+		LogText:  metaMessage.logText,
+		Package:  metaMessage.pkgPath,
+	}
+}
+
+// CheckMetaMessage reports a severity code disagreeing with the HTTP code, i.e.
+// `I` takes 1xx to 3xx, `W` takes 4xx, and `E` or `F` take 5xx.
+func CheckMetaMessage(metaMessage *MetaMessage) error {
+	severity, httpCode := metaMessage.code[0], metaMessage.httpCode
+	var ok bool
+	switch severity {
+	case 'I':
+		ok = httpCode < 400
+	case 'W':
+		ok = httpCode >= 400 && httpCode < 500
+	case 'E', 'F':
+		ok = httpCode >= 500
+	default:
+		return fmt.Errorf("Meta message `%s` has unknown severity code `%c`.", metaMessage.code, severity)
+	}
+	if !ok {
+		return fmt.Errorf("Meta message `%s` has severity code `%c` disagreeing with HTTP code `%d`.",
+			metaMessage.code, severity, httpCode)
+	}
+	return nil
+}
+
+func CheckMetaMessages() []error {
+	errs := []error{}
+	for _, metaMessage := range ListMetaMessages() {
+		if err := CheckMetaMessage(metaMessage); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,136 @@
+package xbmtmsg
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
+)
+
+const maxAcceptLanguages = 16
+
+var (
+	localeMutex       sync.RWMutex
+	localeFallbackMap = map[string][]string{}
+)
+
+// NormalizeLocale formats a language tag regardless of its case and separators,
+// e.g. `zh_hant_tw` becomes `zh-Hant-TW`.
+func NormalizeLocale(locale string) string {
+	parts := strings.FieldsFunc(strings.TrimSpace(locale), func(char rune) bool {
+		return char == '-' || char == '_'
+	})
+	for i, part := range parts {
+		switch {
+		case i == 0:
+			parts[i] = strings.ToLower(part)
+		case len(part) == 4:
+			parts[i] = strings.ToUpper(part[:1]) + strings.ToLower(part[1:])
+		case len(part) == 2:
+			parts[i] = strings.ToUpper(part)
+		default:
+			parts[i] = strings.ToLower(part)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// SetLocaleFallbacks replaces the fallback chain of a locale, which otherwise
+// drops its last subtag at each step, e.g. `zh-Hant-TW`, `zh-Hant` and `zh`.
+// The fallbacks are followed by their own chains, e.g. `zh-HK` falling back
+// to `zh-TW` reaches `zh` afterwards.
+func SetLocaleFallbacks(locale string, fallbacks ...string) {
+	localeMutex.Lock()
+	defer localeMutex.Unlock()
+	normalized := make([]string, len(fallbacks))
+	for i, fallback := range fallbacks {
+		normalized[i] = NormalizeLocale(fallback)
+	}
+	localeFallbackMap[NormalizeLocale(locale)] = normalized
+}
+
+// GetDefaultLocale is the locale of the registered out texts, which ends every
+// negotiated chain.
+func GetDefaultLocale() string {
+	return NormalizeLocale(xbcfg.GetHTTPConfig().Locale)
+}
+
+// NegotiateLocales orders the locales of an `Accept-Language` header by their
+// quality, e.g. `fr-CH, fr;q=0.9, en;q=0.8`, and expands their fallback chains
+// up to the default locale.
+func NegotiateLocales(acceptLanguage string) []string {
+	localeMutex.RLock()
+	defer localeMutex.RUnlock()
+	locales := []string{}
+	for _, locale := range parseAcceptLanguage(acceptLanguage) {
+		locales = expandLocale(locales, locale)
+	}
+	return expandLocale(locales, GetDefaultLocale())
+}
+
+type acceptLanguage struct {
+	locale  string
+	quality float64
+}
+
+func parseAcceptLanguage(header string) []string {
+	items := []*acceptLanguage{}
+	for _, part := range strings.Split(header, ",") {
+		if len(items) == maxAcceptLanguages {
+			break
+		}
+		tag, params, _ := strings.Cut(part, ";")
+		if tag = strings.TrimSpace(tag); tag == "" || tag == "*" {
+			continue
+		}
+		item := &acceptLanguage{locale: NormalizeLocale(tag), quality: 1}
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			quality, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			item.quality = quality
+		}
+		if item.quality > 0 {
+			items = append(items, item)
+		}
+	}
+	slices.SortStableFunc(items, func(a, b *acceptLanguage) int {
+		switch {
+		case a.quality > b.quality:
+			return -1
+		case a.quality < b.quality:
+			return 1
+		}
+		return 0
+	})
+	locales := make([]string, len(items))
+	for i, item := range items {
+		locales[i] = item.locale
+	}
+	return locales
+}
+
+func expandLocale(locales []string, locale string) []string {
+	if locale == "" || slices.Contains(locales, locale) {
+		return locales
+	}
+	locales = append(locales, locale)
+	if fallbacks, ok := localeFallbackMap[locale]; ok {
+		for _, fallback := range fallbacks {
+			locales = expandLocale(locales, fallback)
+		}
+		return locales
+	}
+	if index := strings.LastIndex(locale, "-"); index > 0 {
+		locales = expandLocale(locales, locale[:index])
+	}
+	return locales
+}
+
+func getLanguage(locale string) string {
+	language, _, _ := strings.Cut(locale, "-")
+	return language
+}
@@ -2,11 +2,13 @@ package xbmtmsg
 
 import (
 	"fmt"
+	"maps"
 	"net/http"
 	"regexp"
+	"runtime"
+	"strings"
 
 	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
-	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbctnr"
 )
 
 // Code: {Severity Code (1)}{Project Code (1)}{Service Code (1)}{Sequence Number (3)}
@@ -16,6 +18,9 @@ import (
 // Sequence number: 000, 001, 002, 003, 004, 005, ...
 var (
 	// RESTful view
+	IMV200 = NewMetaMessage(http.StatusOK,
+		"IMV200", "RESTful view: OK.",
+		"OK.")
 	WMV400 = NewMetaMessage(http.StatusBadRequest,
 		"WMV400", "RESTful view: Bad request.",
 		"Bad request.")
@@ -25,9 +30,19 @@ var (
 	WMV404 = NewMetaMessage(http.StatusNotFound,
 		"WMV404", "RESTful view: Not found.",
 		"Not found.")
+	WMV409 = NewMetaMessage(http.StatusConflict,
+		"WMV409", "RESTful view: Conflict.",
+		"Conflict.")
 	EMV500 = NewMetaMessage(http.StatusInternalServerError,
 		"EMV500", "RESTful view: Internal server error.",
 		"Internal server error.")
+	EMV503 = NewMetaMessage(http.StatusServiceUnavailable,
+		"EMV503", "RESTful view: Service unavailable.",
+		"Service unavailable.")
+	// 499 is the non-standard status of a request closed by its client.
+	WMV499 = NewMetaMessage(499,
+		"WMV499", "RESTful view: Client closed request.",
+		"Client closed request.")
 	WMV450 = NewMetaMessage(http.StatusBadRequest,
 		"WMV450", "RESTful view: Invalid parameter.",
 		"Request params must be bound correctly.")
@@ -40,6 +55,38 @@ var (
 	WMV453 = NewMetaMessage(http.StatusBadRequest,
 		"WMV453", "RESTful view: Invalid parameter.",
 		"Request body must be bound correctly.")
+
+	// RESTful view: field details
+	WMV460 = NewMetaMessage(http.StatusBadRequest,
+		"WMV460", "{field} is required.",
+		"Request field is required.")
+	WMV461 = NewMetaMessage(http.StatusBadRequest,
+		"WMV461", "{field} must be at least {param}.",
+		"Request field is below the minimum.")
+	WMV462 = NewMetaMessage(http.StatusBadRequest,
+		"WMV462", "{field} must be at most {param}.",
+		"Request field is above the maximum.")
+	WMV463 = NewMetaMessage(http.StatusBadRequest,
+		"WMV463", "{field} must be greater than {param}.",
+		"Request field isn't above the bound.")
+	WMV464 = NewMetaMessage(http.StatusBadRequest,
+		"WMV464", "{field} must be less than {param}.",
+		"Request field isn't below the bound.")
+	WMV465 = NewMetaMessage(http.StatusBadRequest,
+		"WMV465", "{field} must have length {param}.",
+		"Request field has invalid length.")
+	WMV466 = NewMetaMessage(http.StatusBadRequest,
+		"WMV466", "{field} must be one of {param}.",
+		"Request field isn't one of the options.")
+	WMV467 = NewMetaMessage(http.StatusBadRequest,
+		"WMV467", "{field} must be a valid {rule}.",
+		"Request field has invalid format.")
+	WMV468 = NewMetaMessage(http.StatusBadRequest,
+		"WMV468", "{field} must be of type {param}.",
+		"Request field has invalid type.")
+	WMV469 = NewMetaMessage(http.StatusBadRequest,
+		"WMV469", "{field} must satisfy rule {rule}.",
+		"Request field fails the rule.")
 )
 
 func NewMetaMessage(httpCode int, code, outText, logText string) *MetaMessage {
@@ -48,9 +95,9 @@ func NewMetaMessage(httpCode int, code, outText, logText string) *MetaMessage {
 		setCode(code).
 		setHTTPCode(httpCode).
 		setLogText(logText).
-		setOutCode().
 		setOutText(outText).
-		updateCodeSet().
+		setPackage().
+		updateCatalogue().
 		build()
 	return metaMessage
 }
@@ -59,8 +106,11 @@ type MetaMessage struct {
 	code     string
 	httpCode int
 	logText  string
-	outCode  string
 	outText  string
+	pkgPath  string
+
+	outTemplate *messageTemplate
+	logTemplate *messageTemplate
 }
 
 func (metaMessage *MetaMessage) GetCode() string {
@@ -72,15 +122,45 @@ func (metaMessage *MetaMessage) GetHTTPCode() int {
 }
 
 func (metaMessage *MetaMessage) GetLogText(logArgs ...any) string {
-	return fmt.Sprintf("(%s) %s", metaMessage.code, fmt.Sprintf(metaMessage.logText, logArgs...))
+	return fmt.Sprintf("(%s) %s", metaMessage.code, renderText(metaMessage.logText, logArgs))
 }
 
+// GetOutCode prefixes the code with the service code, which is read on demand
+// since the messages are created before the config is loaded.
 func (metaMessage *MetaMessage) GetOutCode() string {
-	return metaMessage.outCode
+	return fmt.Sprintf("%s-%s", xbcfg.GetServiceCode(), metaMessage.code)
 }
 
+// GetOutText renders the out text of the default locale, taking either the
+// `fmt` arguments or the named ones of `Args`, depending on the text.
 func (metaMessage *MetaMessage) GetOutText(outArgs ...any) string {
-	return fmt.Sprintf(metaMessage.outText, outArgs...)
+	return renderText(metaMessage.outText, outArgs)
+}
+
+// ValidateOutArgs reports the out arguments which wouldn't fill the out text,
+// e.g. a missing named one, which would be shown to the clients as is.
+func (metaMessage *MetaMessage) ValidateOutArgs(outArgs ...any) error {
+	if err := metaMessage.outTemplate.validate(outArgs); err != nil {
+		return fmt.Errorf("Meta message `%s` out text %s.", metaMessage.code, err)
+	}
+	return nil
+}
+
+func (metaMessage *MetaMessage) ValidateLogArgs(logArgs ...any) error {
+	if err := metaMessage.logTemplate.validate(logArgs); err != nil {
+		return fmt.Errorf("Meta message `%s` log text %s.", metaMessage.code, err)
+	}
+	return nil
+}
+
+// GetOutPlaceholders maps the named placeholders of the out text to their
+// types, e.g. `int` of `{count:int}`.
+func (metaMessage *MetaMessage) GetOutPlaceholders() map[string]string {
+	return maps.Clone(metaMessage.outTemplate.placeholders)
+}
+
+func (metaMessage *MetaMessage) GetPackage() string {
+	return metaMessage.pkgPath
 }
 
 func (metaMessage *MetaMessage) String() string {
@@ -89,7 +169,7 @@ func (metaMessage *MetaMessage) String() string {
 }
 
 var (
-	metaMessageCodeSet   = xbctnr.NewSet[string]()
+	metaMessageCatalogue = map[string]*MetaMessage{}
 	metaMessageCodeRegex = regexp.MustCompile(`^[A-Z]{3}[0-9]{3}$`)
 )
 
@@ -107,7 +187,7 @@ func (builder *metaMessageBuilder) initialize() *metaMessageBuilder {
 }
 
 func (builder *metaMessageBuilder) setCode(code string) *metaMessageBuilder {
-	if ok := metaMessageCodeSet.Has(code); ok {
+	if _, ok := metaMessageCatalogue[code]; ok {
 		panic(fmt.Sprintf("Duplicate meta message code `%s` is found.", code))
 	}
 	if ok := metaMessageCodeRegex.MatchString(code); !ok {
@@ -127,21 +207,52 @@ func (builder *metaMessageBuilder) setHTTPCode(httpCode int) *metaMessageBuilder
 }
 
 func (builder *metaMessageBuilder) setLogText(logText string) *metaMessageBuilder {
+	template, err := parseTemplate(logText)
+	if err != nil {
+		panic(fmt.Sprintf("Meta message `%s` has invalid log text: %v", builder.metaMessage.code, err))
+	}
 	builder.metaMessage.logText = logText
+	builder.metaMessage.logTemplate = template
 	return builder
 }
 
-func (builder *metaMessageBuilder) setOutCode() *metaMessageBuilder {
-	builder.metaMessage.outCode = fmt.Sprintf("%s-%s", xbcfg.GetServiceCode(), builder.metaMessage.code)
+func (builder *metaMessageBuilder) setOutText(outText string) *metaMessageBuilder {
+	template, err := parseTemplate(outText)
+	if err != nil {
+		panic(fmt.Sprintf("Meta message `%s` has invalid out text: %v", builder.metaMessage.code, err))
+	}
+	builder.metaMessage.outText = outText
+	builder.metaMessage.outTemplate = template
 	return builder
 }
 
-func (builder *metaMessageBuilder) setOutText(outText string) *metaMessageBuilder {
-	builder.metaMessage.outText = outText
+// setPackage records the package declaring the message, skipping the frames
+// of the builder and NewMetaMessage.
+func (builder *metaMessageBuilder) setPackage() *metaMessageBuilder {
+	pc, _, _, ok := runtime.Caller(2)
+	if !ok {
+		return builder
+	}
+	if function := runtime.FuncForPC(pc); function != nil {
+		builder.metaMessage.pkgPath = getPackagePath(function.Name())
+	}
 	return builder
 }
 
-func (builder *metaMessageBuilder) updateCodeSet() *metaMessageBuilder {
-	metaMessageCodeSet.Add(builder.metaMessage.code)
+func (builder *metaMessageBuilder) updateCatalogue() *metaMessageBuilder {
+	if err := mTranslations.validate(builder.metaMessage); err != nil {
+		panic(err.Error())
+	}
+	metaMessageCatalogue[builder.metaMessage.code] = builder.metaMessage
 	return builder
 }
+
+// getPackagePath strips the receiver and function names, e.g. `a/b/pkg` of
+// `a/b/pkg.init`.
+func getPackagePath(function string) string {
+	index := strings.LastIndex(function, "/") + 1
+	if dot := strings.Index(function[index:], "."); dot >= 0 {
+		return function[:index+dot]
+	}
+	return function
+}
@@ -0,0 +1,240 @@
+package xbmtmsg
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	PlaceholderAny    = "any"
+	PlaceholderString = "string"
+	PlaceholderInt    = "int"
+	PlaceholderFloat  = "float"
+	PlaceholderBool   = "bool"
+)
+
+var placeholderTypes = []string{PlaceholderAny, PlaceholderString, PlaceholderInt, PlaceholderFloat, PlaceholderBool}
+
+var verbRegex = regexp.MustCompile(`%(?:%|[-+# 0]*(?:\[(\d+)\])?(\*|\d+)?(?:\.(\*|\d+)?)?[A-Za-z])`)
+
+// messageTemplate describes the arguments of a text, which is either named,
+// filled by `Args` through its placeholders, or positional, filled by its `fmt`
+// verbs, see `renderText`.
+type messageTemplate struct {
+	text         string
+	placeholders map[string]string
+	arity        int
+}
+
+func parseTemplate(text string) (*messageTemplate, error) {
+	template := &messageTemplate{text: text, placeholders: map[string]string{}}
+	for _, match := range placeholderRegex.FindAllStringSubmatch(text, -1) {
+		name, kind := match[1], match[2]
+		if kind == "" {
+			kind = PlaceholderAny
+		}
+		if !slices.Contains(placeholderTypes, kind) {
+			return nil, fmt.Errorf("Placeholder `%s` has unknown type `%s`.", match[0], kind)
+		}
+		if prev, ok := template.placeholders[name]; ok && prev != kind {
+			return nil, fmt.Errorf("Placeholder `%s` is typed both `%s` and `%s`.", name, prev, kind)
+		}
+		template.placeholders[name] = kind
+	}
+	if template.isNamed() {
+		return template, nil
+	}
+	index := 0
+	for _, match := range verbRegex.FindAllStringSubmatch(text, -1) {
+		if match[0] == "%%" {
+			continue
+		}
+		if match[1] != "" {
+			index, _ = strconv.Atoi(match[1])
+			index--
+		}
+		if match[2] == "*" {
+			index++
+		}
+		if match[3] == "*" {
+			index++
+		}
+		index++
+		template.arity = max(template.arity, index)
+	}
+	return template, nil
+}
+
+func (template *messageTemplate) isNamed() bool {
+	return len(template.placeholders) > 0
+}
+
+// validate reports the arguments which would render the template incompletely,
+// i.e. the missing, unknown or mistyped named ones and a wrong number of the
+// positional ones. The plural key is allowed even without a placeholder.
+func (template *messageTemplate) validate(args []any) error {
+	namedArgs, ok := getNamedArgs(args)
+	if !template.isNamed() {
+		if ok {
+			return fmt.Errorf("takes %d positional argument(s) but got named ones", template.arity)
+		}
+		if len(args) != template.arity {
+			return fmt.Errorf("takes %d positional argument(s) but got %d", template.arity, len(args))
+		}
+		return nil
+	}
+	if !ok {
+		return fmt.Errorf("takes named arguments `%s` but got %d positional one(s)",
+			strings.Join(template.getNames(), "|"), len(args))
+	}
+	for _, name := range template.getNames() {
+		value, ok := namedArgs[name]
+		if !ok {
+			return fmt.Errorf("misses argument `%s`", name)
+		}
+		if kind := template.placeholders[name]; !matchPlaceholderType(kind, value) {
+			return fmt.Errorf("takes argument `%s` of type `%s` but got `%T`", name, kind, value)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(namedArgs)) {
+		if _, ok := template.placeholders[name]; !ok && name != PluralKey {
+			return fmt.Errorf("has no placeholder of argument `%s`", name)
+		}
+	}
+	return nil
+}
+
+// validateTranslation checks that the forms of a translation only take the
+// arguments of the template, with the same types when they are given.
+func (template *messageTemplate) validateTranslation(translation Translation) error {
+	for _, category := range slices.Sorted(maps.Keys(translation)) {
+		form, err := parseTemplate(translation[category])
+		if err != nil {
+			return fmt.Errorf("has invalid form `%s`: %v", category, err)
+		}
+		if err := template.validateForm(form); err != nil {
+			return fmt.Errorf("form `%s` %s", category, err)
+		}
+	}
+	return nil
+}
+
+func (template *messageTemplate) validateForm(form *messageTemplate) error {
+	if !template.isNamed() {
+		if form.isNamed() || form.arity != template.arity {
+			return fmt.Errorf("must take %d positional argument(s) as the out text", template.arity)
+		}
+		return nil
+	}
+	for name, kind := range form.placeholders {
+		prev, ok := template.placeholders[name]
+		if !ok && name != PluralKey {
+			return fmt.Errorf("has placeholder `%s` which the out text doesn't have", name)
+		}
+		if ok && kind != PlaceholderAny && kind != prev {
+			return fmt.Errorf("types placeholder `%s` as `%s` but the out text types it as `%s`", name, kind, prev)
+		}
+	}
+	return nil
+}
+
+func (template *messageTemplate) getNames() []string {
+	return slices.Sorted(maps.Keys(template.placeholders))
+}
+
+func matchPlaceholderType(kind string, value any) bool {
+	switch kind {
+	case PlaceholderString:
+		_, ok := value.(string)
+		return ok
+	case PlaceholderInt:
+		_, ok := getPluralCount(value)
+		return ok
+	case PlaceholderFloat:
+		switch value.(type) {
+		case float32, float64:
+			return true
+		}
+		_, ok := getPluralCount(value)
+		return ok
+	case PlaceholderBool:
+		_, ok := value.(bool)
+		return ok
+	}
+	return true
+}
+
+// ArgsBuilder makes the named arguments of a meta message text, checking each
+// one against its placeholder, e.g.
+// `WAS001.NewOutArgs().SetInt("count", 3).SetString("name", name).MustBuild()`
+// gives the `OutArgs` of `xberror.Options`.
+type ArgsBuilder struct {
+	code     string
+	kind     string
+	template *messageTemplate
+	args     Args
+	errs     []error
+}
+
+func (metaMessage *MetaMessage) NewOutArgs() *ArgsBuilder {
+	return &ArgsBuilder{code: metaMessage.code, kind: "out", template: metaMessage.outTemplate, args: Args{}}
+}
+
+func (metaMessage *MetaMessage) NewLogArgs() *ArgsBuilder {
+	return &ArgsBuilder{code: metaMessage.code, kind: "log", template: metaMessage.logTemplate, args: Args{}}
+}
+
+func (builder *ArgsBuilder) Set(name string, value any) *ArgsBuilder {
+	builder.args[name] = value
+	return builder
+}
+
+func (builder *ArgsBuilder) SetString(name string, value string) *ArgsBuilder {
+	return builder.setTyped(name, PlaceholderString, value)
+}
+
+func (builder *ArgsBuilder) SetInt(name string, value int) *ArgsBuilder {
+	return builder.setTyped(name, PlaceholderInt, value)
+}
+
+func (builder *ArgsBuilder) SetFloat(name string, value float64) *ArgsBuilder {
+	return builder.setTyped(name, PlaceholderFloat, value)
+}
+
+func (builder *ArgsBuilder) SetBool(name string, value bool) *ArgsBuilder {
+	return builder.setTyped(name, PlaceholderBool, value)
+}
+
+func (builder *ArgsBuilder) setTyped(name, kind string, value any) *ArgsBuilder {
+	if prev, ok := builder.template.placeholders[name]; ok && prev != PlaceholderAny && prev != kind &&
+		!(prev == PlaceholderFloat && kind == PlaceholderInt) {
+		builder.errs = append(builder.errs, fmt.Errorf("Meta message `%s` %s text takes argument `%s` of type `%s` but got `%s`.",
+			builder.code, builder.kind, name, prev, kind))
+	}
+	return builder.Set(name, value)
+}
+
+// Build returns the arguments as the only element of a slice, as they are
+// passed to `GetOutText` and `GetLogText`.
+func (builder *ArgsBuilder) Build() ([]any, error) {
+	if len(builder.errs) > 0 {
+		return nil, builder.errs[0]
+	}
+	args := []any{builder.args}
+	if err := builder.template.validate(args); err != nil {
+		return nil, fmt.Errorf("Meta message `%s` %s text %s.", builder.code, builder.kind, err)
+	}
+	return args, nil
+}
+
+func (builder *ArgsBuilder) MustBuild() []any {
+	args, err := builder.Build()
+	if err != nil {
+		panic(err.Error())
+	}
+	return args
+}
@@ -0,0 +1,151 @@
+package xbmtmsg
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParseTemplate(t *testing.T) {
+	cases := []struct {
+		text         string
+		placeholders map[string]string
+		arity        int
+		err          string
+	}{
+		{text: "Plain text.", placeholders: map[string]string{}},
+		{text: "Hello %s, %d%% done.", placeholders: map[string]string{}, arity: 2},
+		{text: "%[2]s before %[1]s.", placeholders: map[string]string{}, arity: 2},
+		{text: "Width %*d.", placeholders: map[string]string{}, arity: 2},
+		{text: "{name} has {count:int} items.", placeholders: map[string]string{"name": PlaceholderAny, "count": PlaceholderInt}},
+		{text: "{name:string} and {name:string}.", placeholders: map[string]string{"name": PlaceholderString}},
+		{text: "{name:date}.", err: "unknown type"},
+		{text: "{name:int} and {name:string}.", err: "is typed both"},
+	}
+	for _, c := range cases {
+		template, err := parseTemplate(c.text)
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("parseTemplate(%q) error = %v, want %q", c.text, err, c.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseTemplate(%q) error = %v", c.text, err)
+			continue
+		}
+		if template.arity != c.arity {
+			t.Errorf("parseTemplate(%q) arity = %d, want %d", c.text, template.arity, c.arity)
+		}
+		if len(template.placeholders) != len(c.placeholders) {
+			t.Errorf("parseTemplate(%q) placeholders = %v, want %v", c.text, template.placeholders, c.placeholders)
+			continue
+		}
+		for name, kind := range c.placeholders {
+			if template.placeholders[name] != kind {
+				t.Errorf("parseTemplate(%q) placeholder `%s` = %q, want %q", c.text, name, template.placeholders[name], kind)
+			}
+		}
+	}
+}
+
+func TestMessageTemplateValidate(t *testing.T) {
+	cases := []struct {
+		text string
+		args []any
+		err  string
+	}{
+		{text: "Hello %s.", args: []any{"a"}},
+		{text: "Hello %s.", args: nil, err: "takes 1 positional argument(s) but got 0"},
+		{text: "Hello %s.", args: []any{Args{"name": "a"}}, err: "got named ones"},
+		{text: "{name} has {count:int}.", args: []any{Args{"name": "a", "count": 2}}},
+		{text: "{name} has {count:int}.", args: []any{Args{"name": "a", "count": uint8(2)}}},
+		{text: "{name} has {count:int}.", args: []any{"a", 2}, err: "got 2 positional one(s)"},
+		{text: "{name} has {count:int}.", args: []any{Args{"name": "a"}}, err: "misses argument `count`"},
+		{text: "{name} has {count:int}.", args: []any{Args{"name": "a", "count": "2"}}, err: "of type `int` but got `string`"},
+		{text: "{name}.", args: []any{Args{"name": "a", "other": 1}}, err: "has no placeholder of argument `other`"},
+		{text: "{name}.", args: []any{Args{"name": "a", PluralKey: 1}}},
+		{text: "{ratio:float}.", args: []any{Args{"ratio": 1}}},
+		{text: "{ok:bool}.", args: []any{Args{"ok": 1}}, err: "of type `bool` but got `int`"},
+	}
+	for _, c := range cases {
+		template, err := parseTemplate(c.text)
+		if err != nil {
+			t.Fatalf("parseTemplate(%q) error = %v", c.text, err)
+		}
+		err = template.validate(c.args)
+		if c.err == "" && err != nil {
+			t.Errorf("validate(%q, %v) error = %v", c.text, c.args, err)
+		}
+		if c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)) {
+			t.Errorf("validate(%q, %v) error = %v, want %q", c.text, c.args, err, c.err)
+		}
+	}
+}
+
+func TestMessageTemplateValidateTranslation(t *testing.T) {
+	cases := []struct {
+		text        string
+		translation Translation
+		err         string
+	}{
+		{text: "Hello %s.", translation: Translation{PluralOther: "Hallo %s."}},
+		{text: "Hello %s.", translation: Translation{PluralOther: "Hallo."}, err: "must take 1 positional argument(s)"},
+		{text: "Hello %s.", translation: Translation{PluralOther: "Hallo {name}."}, err: "must take 1 positional argument(s)"},
+		{text: "{name} has {count:int}.", translation: Translation{PluralOne: "{name} hat eins.", PluralOther: "{name} hat {count}."}},
+		{text: "{name} has {count:int}.", translation: Translation{PluralOther: "{name} hat {count:int}."}},
+		{text: "{name}.", translation: Translation{PluralOne: "{name} ({count})."}},
+		{text: "{name}.", translation: Translation{PluralOther: "{user}."}, err: "form `other` has placeholder `user`"},
+		{text: "{count:int}.", translation: Translation{PluralOther: "{count:string}."}, err: "types placeholder `count` as `string`"},
+		{text: "{name}.", translation: Translation{PluralOther: "{name:date}."}, err: "has invalid form `other`"},
+	}
+	for _, c := range cases {
+		template, err := parseTemplate(c.text)
+		if err != nil {
+			t.Fatalf("parseTemplate(%q) error = %v", c.text, err)
+		}
+		err = template.validateTranslation(c.translation)
+		if c.err == "" && err != nil {
+			t.Errorf("validateTranslation(%q, %v) error = %v", c.text, c.translation, err)
+		}
+		if c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)) {
+			t.Errorf("validateTranslation(%q, %v) error = %v, want %q", c.text, c.translation, err, c.err)
+		}
+	}
+}
+
+func TestArgsBuilder(t *testing.T) {
+	message := NewMetaMessage(http.StatusBadRequest,
+		"WMT001", "{name:string} has {ratio:float} left.",
+		"{name} sent {count:int} items.")
+
+	args, err := message.NewOutArgs().SetString("name", "a").SetInt("ratio", 1).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if text := message.GetOutText(args...); text != "a has 1 left." {
+		t.Errorf("GetOutText() = %q", text)
+	}
+	args, err = message.NewLogArgs().Set("name", "a").SetInt("count", 3).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if text := message.GetLogText(args...); text != "(WMT001) a sent 3 items." {
+		t.Errorf("GetLogText() = %q", text)
+	}
+
+	if _, err := message.NewOutArgs().SetBool("name", true).SetFloat("ratio", 0.5).Build(); err == nil ||
+		!strings.Contains(err.Error(), "argument `name` of type `string` but got `bool`") {
+		t.Errorf("Build() error = %v, want a mistyped `name`", err)
+	}
+	if _, err := message.NewOutArgs().SetString("name", "a").Build(); err == nil ||
+		!strings.Contains(err.Error(), "misses argument `ratio`") {
+		t.Errorf("Build() error = %v, want a missing `ratio`", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("MustBuild() didn't panic on a missing argument")
+		}
+	}()
+	message.NewLogArgs().Set("name", "a").MustBuild()
+}
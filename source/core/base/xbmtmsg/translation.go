@@ -0,0 +1,316 @@
+package xbmtmsg
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbjson"
+)
+
+// PluralKey names the argument choosing the plural form of a translation.
+const PluralKey = "count"
+
+const (
+	PluralZero  = "zero"
+	PluralOne   = "one"
+	PluralTwo   = "two"
+	PluralFew   = "few"
+	PluralMany  = "many"
+	PluralOther = "other"
+)
+
+var pluralCategories = []string{PluralZero, PluralOne, PluralTwo, PluralFew, PluralMany, PluralOther}
+
+// Args fills the named placeholders of a text, e.g. `{name}` or `{count:int}`,
+// instead of the `fmt` verbs when it is given as the only argument.
+type Args map[string]any
+
+// Translation holds the forms of a translated out text by CLDR plural category,
+// where `other` is required and is the only one of a text without plurals.
+type Translation map[string]string
+
+// PluralRule chooses the plural category of a count in a language.
+type PluralRule = func(count int64) string
+
+var pluralRuleMap = map[string]PluralRule{
+	"en": pluralRuleOneOther, "de": pluralRuleOneOther, "nl": pluralRuleOneOther,
+	"it": pluralRuleOneOther, "es": pluralRuleOneOther, "sv": pluralRuleOneOther,
+	"fr": pluralRuleFrench, "pt": pluralRuleFrench,
+	"ru": pluralRuleSlavic, "uk": pluralRuleSlavic, "pl": pluralRulePolish,
+	"zh": pluralRuleOther, "ja": pluralRuleOther, "ko": pluralRuleOther,
+	"th": pluralRuleOther, "vi": pluralRuleOther, "id": pluralRuleOther,
+}
+
+var placeholderRegex = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)(?::([A-Za-z]+))?\}`)
+
+var mTranslations = &translations{translationMap: map[string]map[string]Translation{}}
+
+type translations struct {
+	mutex          sync.RWMutex
+	translationMap map[string]map[string]Translation
+}
+
+func RegisterPluralRule(language string, rule PluralRule) {
+	language = NormalizeLocale(language)
+	if _, ok := pluralRuleMap[language]; ok {
+		panic(fmt.Sprintf("Duplicate plural rule `%s` is found.", language))
+	}
+	pluralRuleMap[language] = rule
+}
+
+// RegisterTranslation adds the translation of a meta message code in a locale,
+// replacing the previous one if any. The forms are checked against the out
+// text of the message, either now or once it is registered.
+func RegisterTranslation(locale, code string, translation Translation) {
+	if err := mTranslations.add(locale, code, translation); err != nil {
+		panic(err.Error())
+	}
+}
+
+// LoadTranslations loads the bundles matched by the patterns of a file system,
+// e.g. an `embed.FS`. Each bundle is a JSON or YAML file named after its
+// locale, e.g. `locales/zh-TW.yaml`, mapping the codes to their texts or to
+// the forms by plural category.
+func LoadTranslations(fsys fs.FS, patterns ...string) error {
+	for _, pattern := range patterns {
+		paths, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return fmt.Errorf("Translation pattern `%s` is malformed: %w", pattern, err)
+		}
+		for _, path := range paths {
+			if err := loadTranslationBundle(fsys, path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func loadTranslationBundle(fsys fs.FS, filePath string) error {
+	data, err := fs.ReadFile(fsys, filePath)
+	if err != nil {
+		return fmt.Errorf("Translation bundle `%s` cannot be read: %w", filePath, err)
+	}
+	bundle := map[string]any{}
+	extension := path.Ext(filePath)
+	switch extension {
+	case ".json":
+		err = xbjson.Unmarshal(data, &bundle)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &bundle)
+	default:
+		return fmt.Errorf("Translation bundle `%s` must be a JSON or YAML file.", filePath)
+	}
+	if err != nil {
+		return fmt.Errorf("Translation bundle `%s` cannot be decoded: %w", filePath, err)
+	}
+	locale := strings.TrimSuffix(path.Base(filePath), extension)
+	for code, value := range bundle {
+		translation, err := makeTranslation(value)
+		if err == nil {
+			err = mTranslations.add(locale, code, translation)
+		}
+		if err != nil {
+			return fmt.Errorf("Translation bundle `%s` has invalid code `%s`: %w", filePath, code, err)
+		}
+	}
+	return nil
+}
+
+func makeTranslation(value any) (Translation, error) {
+	switch value := value.(type) {
+	case string:
+		return Translation{PluralOther: value}, nil
+	case map[string]any:
+		translation := Translation{}
+		for category, form := range value {
+			text, ok := form.(string)
+			if !ok {
+				return nil, fmt.Errorf("Plural form `%s` must be a string.", category)
+			}
+			translation[category] = text
+		}
+		return translation, nil
+	}
+	return nil, fmt.Errorf("Translation must be a string or a map of plural forms.")
+}
+
+func (translations *translations) add(locale, code string, translation Translation) error {
+	if _, ok := translation[PluralOther]; !ok {
+		return fmt.Errorf("Translation of `%s` in `%s` must have plural form `%s`.", code, locale, PluralOther)
+	}
+	for category := range translation {
+		if !slices.Contains(pluralCategories, category) {
+			return fmt.Errorf("Translation of `%s` in `%s` has unknown plural form `%s`.", code, locale, category)
+		}
+	}
+	if metaMessage, ok := metaMessageCatalogue[code]; ok {
+		if err := metaMessage.outTemplate.validateTranslation(translation); err != nil {
+			return fmt.Errorf("Translation of `%s` in `%s` %s.", code, locale, err)
+		}
+	}
+	translations.mutex.Lock()
+	defer translations.mutex.Unlock()
+	locale = NormalizeLocale(locale)
+	if _, ok := translations.translationMap[locale]; !ok {
+		translations.translationMap[locale] = map[string]Translation{}
+	}
+	translations.translationMap[locale][code] = translation
+	return nil
+}
+
+// validate checks the translations added before the message is registered.
+func (translations *translations) validate(metaMessage *MetaMessage) error {
+	translations.mutex.RLock()
+	defer translations.mutex.RUnlock()
+	for _, locale := range slices.Sorted(maps.Keys(translations.translationMap)) {
+		if translation, ok := translations.translationMap[locale][metaMessage.code]; ok {
+			if err := metaMessage.outTemplate.validateTranslation(translation); err != nil {
+				return fmt.Errorf("Translation of `%s` in `%s` %s.", metaMessage.code, locale, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (translations *translations) lookup(locales []string, code string) (Translation, string, bool) {
+	defaultLocale := GetDefaultLocale()
+	translations.mutex.RLock()
+	defer translations.mutex.RUnlock()
+	for _, locale := range locales {
+		if translation, ok := translations.translationMap[locale][code]; ok {
+			return translation, locale, true
+		}
+		if locale == defaultLocale {
+			break
+		}
+	}
+	return nil, "", false
+}
+
+// GetLocalOutText renders the out text in the first of the negotiated locales,
+// see `NegotiateLocales`, which translates the message, and returns the locale
+// used. The registered out text is taken as the one of the default locale.
+func (metaMessage *MetaMessage) GetLocalOutText(locales []string, outArgs ...any) (string, string) {
+	translation, locale, ok := mTranslations.lookup(locales, metaMessage.code)
+	if !ok {
+		return metaMessage.GetOutText(outArgs...), GetDefaultLocale()
+	}
+	text := translation[PluralOther]
+	if args, ok := getNamedArgs(outArgs); ok {
+		if count, ok := getPluralCount(args[PluralKey]); ok {
+			if form, ok := translation[getPluralRule(locale)(count)]; ok {
+				text = form
+			}
+		}
+	}
+	return renderText(text, outArgs), locale
+}
+
+// renderText fills the named placeholders, whatever their types, or the `fmt`
+// verbs of a text.
+func renderText(text string, args []any) string {
+	namedArgs, ok := getNamedArgs(args)
+	if !ok {
+		return fmt.Sprintf(text, args...)
+	}
+	return placeholderRegex.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name, _, _ := strings.Cut(placeholder[1:len(placeholder)-1], ":")
+		if value, ok := namedArgs[name]; ok {
+			return fmt.Sprint(value)
+		}
+		return placeholder
+	})
+}
+
+func getNamedArgs(args []any) (Args, bool) {
+	if len(args) != 1 {
+		return nil, false
+	}
+	namedArgs, ok := args[0].(Args)
+	return namedArgs, ok
+}
+
+func getPluralCount(value any) (int64, bool) {
+	switch value := value.(type) {
+	case int:
+		return int64(value), true
+	case int8:
+		return int64(value), true
+	case int16:
+		return int64(value), true
+	case int32:
+		return int64(value), true
+	case int64:
+		return value, true
+	case uint:
+		return int64(value), true
+	case uint8:
+		return int64(value), true
+	case uint16:
+		return int64(value), true
+	case uint32:
+		return int64(value), true
+	case uint64:
+		return int64(value), true
+	}
+	return 0, false
+}
+
+func getPluralRule(locale string) PluralRule {
+	if rule, ok := pluralRuleMap[locale]; ok {
+		return rule
+	}
+	if rule, ok := pluralRuleMap[getLanguage(locale)]; ok {
+		return rule
+	}
+	return pluralRuleOneOther
+}
+
+func pluralRuleOther(count int64) string {
+	return PluralOther
+}
+
+func pluralRuleOneOther(count int64) string {
+	if count == 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+func pluralRuleFrench(count int64) string {
+	if count == 0 || count == 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+func pluralRuleSlavic(count int64) string {
+	mod10, mod100 := count%10, count%100
+	switch {
+	case mod10 == 1 && mod100 != 11:
+		return PluralOne
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return PluralFew
+	}
+	return PluralMany
+}
+
+func pluralRulePolish(count int64) string {
+	mod10, mod100 := count%10, count%100
+	switch {
+	case count == 1:
+		return PluralOne
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return PluralFew
+	}
+	return PluralMany
+}
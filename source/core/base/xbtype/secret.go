@@ -0,0 +1,39 @@
+package xbtype
+
+import "fmt"
+
+const SecretMask = "******"
+
+// Secret holds a sensitive text which is masked whenever it gets printed,
+// logged or marshaled. The raw text is only given by `Reveal`.
+type Secret string
+
+func (secret Secret) Reveal() string {
+	return string(secret)
+}
+
+func (secret Secret) String() string {
+	if secret == "" {
+		return ""
+	}
+	return SecretMask
+}
+
+func (secret Secret) GoString() string {
+	return fmt.Sprintf("xbtype.Secret(%q)", secret.String())
+}
+
+func (secret Secret) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("%q", secret.String())), nil
+}
+
+func (secret Secret) MarshalText() ([]byte, error) {
+	return []byte(secret.String()), nil
+}
+
+// UnmarshalText keeps the raw text, so that secrets can be parsed from the
+// environment and config files like any other field.
+func (secret *Secret) UnmarshalText(text []byte) error {
+	*secret = Secret(text)
+	return nil
+}
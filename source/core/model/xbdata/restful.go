@@ -22,3 +22,13 @@ type JSONResponseBaseData struct {
 }
 
 type JSONResponsePageData = PaginationResult
+
+// JSONResponseError locates a failure of the request, e.g. the field `items.0.name`
+// failing rule `required`, for the clients to highlight it.
+type JSONResponseError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Value   any    `json:"value,omitempty"`
+}
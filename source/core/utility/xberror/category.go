@@ -0,0 +1,131 @@
+package xberror
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbmtmsg"
+)
+
+// Category classifies the errors which are handled alike regardless of their
+// sources, e.g. a unique violation of any database is a conflict, along with
+// the meta message responded for them.
+type Category struct {
+	Name      string
+	Message   *Message
+	Retryable bool
+}
+
+var (
+	CategoryConflict   = &Category{Name: "conflict", Message: xbmtmsg.WMV409}
+	CategoryPermission = &Category{Name: "permission", Message: xbmtmsg.WMV403}
+	CategoryCanceled   = &Category{Name: "canceled", Message: xbmtmsg.WMV499}
+	CategoryTimeout    = &Category{Name: "timeout", Message: xbmtmsg.EMV503, Retryable: true}
+	CategoryRetryable  = &Category{Name: "retryable", Message: xbmtmsg.EMV503, Retryable: true}
+)
+
+// Classifier returns the category of an error itself, i.e. regardless of its
+// causes, or nil if it doesn't recognize the error.
+type Classifier = func(err error) *Category
+
+var classifiers = []Classifier{classifyContextError}
+
+// RegisterClassifier adds a classifier of the errors of a source, e.g. the
+// SQLSTATE codes of `xbgorm`, which is tried after the registered ones.
+func RegisterClassifier(classifier Classifier) {
+	classifiers = append(classifiers, classifier)
+}
+
+// Classify returns the category of the first error along the chain, which is
+// either given by the options of a custom error or recognized by a classifier.
+func Classify(err error) (*Category, bool) {
+	category := classify(err, 0)
+	return category, category != nil
+}
+
+func classify(err error, depth int) *Category {
+	if err == nil {
+		return nil
+	}
+	if cerr, ok := err.(CategorizedError); ok && cerr.Category() != nil {
+		return cerr.Category()
+	}
+	for _, classifier := range classifiers {
+		if category := classifier(err); category != nil {
+			return category
+		}
+	}
+	if depth == maxCauseDepth {
+		return nil
+	}
+	for _, uerr := range Unwrap(err) {
+		if category := classify(uerr, depth+1); category != nil {
+			return category
+		}
+	}
+	return nil
+}
+
+func IsRetryable(err error) bool {
+	category, ok := Classify(err)
+	return ok && category.Retryable
+}
+
+func IsConflict(err error) bool {
+	category, _ := Classify(err)
+	return category == CategoryConflict
+}
+
+func IsPermission(err error) bool {
+	category, _ := Classify(err)
+	return category == CategoryPermission
+}
+
+func IsTimeout(err error) bool {
+	category, _ := Classify(err)
+	return category == CategoryTimeout
+}
+
+func IsCanceled(err error) bool {
+	category, _ := Classify(err)
+	return category == CategoryCanceled
+}
+
+// Categorize makes a classified error a custom one of its category message,
+// i.e. a validation error of status 4xx and an unexpected one otherwise, so
+// that it is responded properly. The custom errors are kept, except the
+// unexpected ones whose causes are classified, e.g. a unique violation wrapped
+// by `Unexpected(EMV500, ...)`, and so are the unclassified errors.
+func Categorize(err error) error {
+	cerr, ok := AsCustomError(err)
+	if ok {
+		if _, ok := cerr.(*UnexpectedError); !ok {
+			return err
+		}
+	}
+	category, found := Classify(err)
+	if !found || (ok && cerr.Message() == category.Message) {
+		return err
+	}
+	ierr := newInternal(category.Message, &Options{Category: category}, []error{err}, 1)
+	if category.Message.GetHTTPCode() < http.StatusInternalServerError {
+		return &ValidationError{InternalError: ierr}
+	}
+	return &UnexpectedError{InternalError: ierr}
+}
+
+// classifyContextError takes the deadline of a context and the network
+// timeouts as timeouts, while the cancellation of a context, e.g. by a client
+// going away, isn't retried.
+func classifyContextError(err error) *Category {
+	if err == context.Canceled {
+		return CategoryCanceled
+	}
+	if err == context.DeadlineExceeded {
+		return CategoryTimeout
+	}
+	if terr, ok := err.(interface{ Timeout() bool }); ok && terr.Timeout() {
+		return CategoryTimeout
+	}
+	return nil
+}
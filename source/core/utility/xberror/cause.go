@@ -0,0 +1,85 @@
+package xberror
+
+import (
+	"fmt"
+	"strings"
+)
+
+const maxCauseDepth = 16
+
+// Cause is a node of the cause tree of an error, made of its own message, i.e.
+// without the ones of its causes, and the stack captured along with it. The
+// root keeps the whole message of a joined error, whose own one is empty.
+type Cause struct {
+	Type    string        `json:"type"`
+	Message string        `json:"message"`
+	Code    string        `json:"code,omitempty"`
+	Stack   []*StackFrame `json:"stack,omitempty"`
+	Causes  []*Cause      `json:"causes,omitempty"`
+}
+
+func MakeCause(err error) *Cause {
+	return makeCause(err, 0)
+}
+
+func makeCause(err error, depth int) *Cause {
+	cause := &Cause{Type: fmt.Sprintf("%T", err), Message: err.Error()}
+	switch err := err.(type) {
+	case *Error:
+		cause.Message = err.message
+	case *WrapError:
+		cause.Message = err.message
+	}
+	if cerr, ok := err.(CustomError); ok {
+		cause.Message = cerr.LogText()
+		cause.Code = cerr.Message().GetCode()
+	}
+	if serr, ok := err.(StackedError); ok {
+		if stack := serr.Stack(); stack != nil {
+			cause.Stack = stack.Frames()
+		}
+	}
+	if depth == maxCauseDepth {
+		return cause
+	}
+	texts := []string{}
+	for _, uerr := range Unwrap(err) {
+		if uerr == nil {
+			continue
+		}
+		subcause := makeCause(uerr, depth+1)
+		cause.Message = strings.TrimSuffix(cause.Message, ": "+uerr.Error())
+		cause.Causes = append(cause.Causes, subcause)
+		texts = append(texts, uerr.Error())
+	}
+	if depth > 0 && len(texts) > 1 && cause.Message == strings.Join(texts, "\n") {
+		cause.Message = ""
+	}
+	return cause
+}
+
+// Render draws the tree as indented text, e.g.
+//
+//	*xberror.ValidationError: (WMV453) Request body must be bound correctly.
+//	    at xbgin.(*RESTFlow).BindBody (/app/restful.go:330)
+//	  caused by *json.SyntaxError: unexpected end of JSON input
+func (cause *Cause) Render() string {
+	builder := &strings.Builder{}
+	cause.render(builder, "")
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+func (cause *Cause) render(builder *strings.Builder, indent string) {
+	if cause.Message == "" {
+		fmt.Fprintf(builder, "%s\n", cause.Type)
+	} else {
+		fmt.Fprintf(builder, "%s: %s\n", cause.Type, cause.Message)
+	}
+	for _, frame := range cause.Stack {
+		fmt.Fprintf(builder, "%s    at %s (%s:%d)\n", indent, frame.Function, frame.File, frame.Line)
+	}
+	for _, subcause := range cause.Causes {
+		fmt.Fprintf(builder, "%s  caused by ", indent)
+		subcause.render(builder, indent+"  ")
+	}
+}
@@ -3,9 +3,11 @@ package xberror
 import (
 	"errors"
 	"fmt"
+	"maps"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
 	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbmtmsg"
 )
 
@@ -57,7 +59,9 @@ func Unwrap(err error) []error {
 
 func Aggravate(err error) error {
 	if verr, ok := AsValidationError(err); ok {
-		err = Unexpected(verr.Message(), verr.Options(), verr.Unwrap()...)
+		uerr := Unexpected(verr.Message(), verr.Options(), verr.Unwrap()...)
+		uerr.stack = verr.stack
+		err = uerr
 	}
 	return err
 }
@@ -110,6 +114,10 @@ type (
 	LogFields = logrus.Fields
 )
 
+// ArgsErrorKey carries the mismatch of the arguments and the message texts in
+// the log fields, see `xbmtmsg.MetaMessage.ValidateOutArgs`.
+var ArgsErrorKey = "argsError"
+
 type NestedError interface {
 	Error() string
 	Unwrap() []error
@@ -126,22 +134,67 @@ type CustomError interface {
 	LogFields() LogFields
 }
 
+// The optional interfaces of a custom error, which are checked on their own so
+// that the custom errors implemented elsewhere don't have to implement them.
+type (
+	DetailedError interface {
+		OutDetails() []*Detail
+	}
+	StackedError interface {
+		Stack() *Stack
+	}
+	CategorizedError interface {
+		Category() *Category
+	}
+)
+
+// GetOutDetails returns the out details of an error implementing
+// `DetailedError`, or nil.
+func GetOutDetails(err error) []*Detail {
+	if derr, ok := err.(DetailedError); ok {
+		return derr.OutDetails()
+	}
+	return nil
+}
+
+// Detail describes a single failure within an error, e.g. an invalid field of
+// a request body, whose message is rendered for the clients along with the
+// rejected value if any.
+type Detail struct {
+	Field   string
+	Rule    string
+	Message *Message
+	OutArgs []any
+	Value   any
+}
+
 type InternalError struct {
-	message   *Message
-	options   *Options
-	errs      []error
-	outArgs   []any
-	logArgs   []any
-	logFields LogFields
+	message    *Message
+	options    *Options
+	errs       []error
+	outArgs    []any
+	outDetails []*Detail
+	logArgs    []any
+	logFields  LogFields
+	stack      *Stack
+	argsErrs   []error
 }
 
 func Internal(message *Message, options *Options, errs ...error) *InternalError {
+	return newInternal(message, options, errs, 1)
+}
+
+// newInternal skips the frames of the given number of constructors, so that the
+// stack starts from their caller.
+func newInternal(message *Message, options *Options, errs []error, skip int) *InternalError {
 	err := (&internalErrorBuilder{options: options}).
 		initialize().
 		setMessage(message).
 		setOptions().
 		setErrors(errs...).
+		setStack(skip + 1).
 		setOutArgs().
+		setOutDetails().
 		setLogArgs().
 		setLogFields().
 		build()
@@ -172,6 +225,10 @@ func (err *InternalError) OutArgs() []any {
 	return err.outArgs
 }
 
+func (err *InternalError) OutDetails() []*Detail {
+	return err.outDetails
+}
+
 func (err *InternalError) LogText() string {
 	return err.message.GetLogText(err.logArgs...)
 }
@@ -184,15 +241,30 @@ func (err *InternalError) LogFields() LogFields {
 	return err.logFields
 }
 
+// Stack returns the call stack captured at construction, or nil unless it is
+// enabled by the options or the config.
+func (err *InternalError) Stack() *Stack {
+	return err.stack
+}
+
+// Category returns the category given by the options, see `Classify` for the
+// one of the whole chain.
+func (err *InternalError) Category() *Category {
+	return err.options.Category
+}
+
 type internalErrorBuilder struct {
 	err     *InternalError
 	options *Options
 }
 
 type internalErrorOptions struct {
-	OutArgs   []any
-	LogArgs   []any
-	LogFields LogFields
+	OutArgs    []any
+	OutDetails []*Detail
+	LogArgs    []any
+	LogFields  LogFields
+	Stack      *bool
+	Category   *Category
 }
 
 func (builder *internalErrorBuilder) build() *InternalError {
@@ -222,18 +294,64 @@ func (builder *internalErrorBuilder) setErrors(errs ...error) *internalErrorBuil
 	return builder
 }
 
+func (builder *internalErrorBuilder) setStack(skip int) *internalErrorBuilder {
+	// Errors may be created before the config is loaded, e.g. in package vars.
+	enabled := false
+	if config, ok := xbcfg.LookupConfig(); ok {
+		enabled = config.GetServiceErrorStack()
+	}
+	if builder.options.Stack != nil {
+		enabled = *builder.options.Stack
+	}
+	if enabled {
+		builder.err.stack = CaptureStack(skip + 1)
+	}
+	return builder
+}
+
 func (builder *internalErrorBuilder) setOutArgs() *internalErrorBuilder {
 	builder.err.outArgs = builder.options.OutArgs
+	builder.checkArgs(builder.err.message.ValidateOutArgs(builder.err.outArgs...))
+	return builder
+}
+
+func (builder *internalErrorBuilder) setOutDetails() *internalErrorBuilder {
+	builder.err.outDetails = builder.options.OutDetails
+	for _, detail := range builder.err.outDetails {
+		builder.checkArgs(detail.Message.ValidateOutArgs(detail.OutArgs...))
+	}
 	return builder
 }
 
 func (builder *internalErrorBuilder) setLogArgs() *internalErrorBuilder {
 	builder.err.logArgs = builder.options.LogArgs
+	builder.checkArgs(builder.err.message.ValidateLogArgs(builder.err.logArgs...))
 	return builder
 }
 
+// checkArgs keeps the mismatch of the arguments and the message texts, which is
+// logged with the error, and only fails on it while testing, so that a served
+// request still gets its response. An error created before the config is
+// loaded isn't taken as testing.
+func (builder *internalErrorBuilder) checkArgs(err error) {
+	if err == nil {
+		return
+	}
+	if config, ok := xbcfg.LookupConfig(); ok && config.GetServiceTesting() {
+		panic(err.Error())
+	}
+	builder.err.argsErrs = append(builder.err.argsErrs, err)
+}
+
 func (builder *internalErrorBuilder) setLogFields() *internalErrorBuilder {
 	builder.err.logFields = builder.options.LogFields
+	if len(builder.err.argsErrs) > 0 {
+		builder.err.logFields = maps.Clone(builder.err.logFields)
+		if builder.err.logFields == nil {
+			builder.err.logFields = LogFields{}
+		}
+		builder.err.logFields[ArgsErrorKey] = errors.Join(builder.err.argsErrs...).Error()
+	}
 	return builder
 }
 
@@ -243,7 +361,7 @@ type ValidationError struct {
 
 func Validation(message *Message, options *Options, errs ...error) *ValidationError {
 	return &ValidationError{
-		InternalError: Internal(message, options, errs...),
+		InternalError: newInternal(message, options, errs, 1),
 	}
 }
 
@@ -257,7 +375,7 @@ type UnexpectedError struct {
 
 func Unexpected(message *Message, options *Options, errs ...error) *UnexpectedError {
 	return &UnexpectedError{
-		InternalError: Internal(message, options, errs...),
+		InternalError: newInternal(message, options, errs, 1),
 	}
 }
 
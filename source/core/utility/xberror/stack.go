@@ -0,0 +1,51 @@
+package xberror
+
+import (
+	"runtime"
+	"sync"
+)
+
+const maxStackDepth = 32
+
+type StackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// Stack keeps the program counters of a call stack, which are only symbolized
+// once the frames are asked for, e.g. when the error is logged.
+type Stack struct {
+	pcs    []uintptr
+	once   sync.Once
+	frames []*StackFrame
+}
+
+// CaptureStack records the call stack of its caller, skipping the given number
+// of frames above it.
+func CaptureStack(skip int) *Stack {
+	pcs := make([]uintptr, maxStackDepth)
+	count := runtime.Callers(skip+2, pcs)
+	return &Stack{pcs: pcs[:count]}
+}
+
+func (stack *Stack) Frames() []*StackFrame {
+	stack.once.Do(func() {
+		if len(stack.pcs) == 0 {
+			return
+		}
+		frames := runtime.CallersFrames(stack.pcs)
+		for {
+			frame, more := frames.Next()
+			stack.frames = append(stack.frames, &StackFrame{
+				Function: frame.Function,
+				File:     frame.File,
+				Line:     frame.Line,
+			})
+			if !more {
+				break
+			}
+		}
+	})
+	return stack.frames
+}
@@ -6,7 +6,10 @@ import (
 	"github.com/bytedance/sonic"
 )
 
-var mJSON JSON
+var (
+	mJSON       JSON
+	mSortedJSON JSON
+)
 
 type (
 	JSON       = sonic.API
@@ -27,11 +30,43 @@ func newJSON() JSON {
 	return json
 }
 
+// getSortedJSON sorts the keys of the maps, e.g. for an output compared across
+// runs, which is slower than the default one.
+func getSortedJSON() JSON {
+	if mSortedJSON == nil {
+		mSortedJSON = newSortedJSON()
+	}
+	return mSortedJSON
+}
+
+func newSortedJSON() JSON {
+	json := sonic.Config{
+		CompactMarshaler: true,
+		SortMapKeys:      true,
+	}.Froze()
+	return json
+}
+
 func Marshal(value any) ([]byte, error) {
 	data, err := getJSON().Marshal(value)
 	return data, err
 }
 
+func MarshalIndent(value any, prefix, indent string) ([]byte, error) {
+	data, err := getJSON().MarshalIndent(value, prefix, indent)
+	return data, err
+}
+
+func MarshalSorted(value any) ([]byte, error) {
+	data, err := getSortedJSON().Marshal(value)
+	return data, err
+}
+
+func MarshalIndentSorted(value any, prefix, indent string) ([]byte, error) {
+	data, err := getSortedJSON().MarshalIndent(value, prefix, indent)
+	return data, err
+}
+
 func Unmarshal(data []byte, value any) error {
 	err := getJSON().Unmarshal(data, value)
 	return err
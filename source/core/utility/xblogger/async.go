@@ -0,0 +1,178 @@
+package xblogger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbmetric"
+)
+
+const (
+	AsyncPolicyDrop  = "drop"
+	AsyncPolicyBlock = "block"
+)
+
+const (
+	defaultAsyncSize    = 4096
+	defaultAsyncPolicy  = AsyncPolicyDrop
+	defaultFlushTimeout = 5 * time.Second
+	asyncFlushInterval  = 5 * time.Millisecond
+)
+
+var droppedCounter = xbmetric.NewCounter("log_entries_dropped_total",
+	"Total number of log entries dropped by full async queues.", "output")
+
+type AsyncWriterOptions struct {
+	Name   *string
+	Size   *int
+	Policy *string
+}
+
+// AsyncWriter takes the writes off the calling goroutine through a bounded
+// queue, which either drops the entries or blocks the callers once full. The
+// entries are still formatted by the callers, so that they never race with
+// the fields changed after logging.
+type AsyncWriter struct {
+	mutex   sync.RWMutex
+	writer  io.Writer
+	name    string
+	policy  string
+	queue   chan asyncItem
+	done    chan struct{}
+	closed  bool
+	queued  atomic.Uint64
+	written atomic.Uint64
+	dropped atomic.Uint64
+}
+
+type asyncItem struct {
+	level Level
+	bytes []byte
+}
+
+func NewAsyncWriter(writer io.Writer, options *AsyncWriterOptions) *AsyncWriter {
+	if options == nil {
+		options = &AsyncWriterOptions{}
+	}
+	size := defaultAsyncSize
+	if options.Size != nil && *options.Size > 0 {
+		size = *options.Size
+	}
+	async := &AsyncWriter{
+		writer: writer,
+		policy: defaultAsyncPolicy,
+		queue:  make(chan asyncItem, size),
+		done:   make(chan struct{}),
+	}
+	if options.Name != nil {
+		async.name = *options.Name
+	}
+	if options.Policy != nil {
+		async.policy = *options.Policy
+	}
+	go async.run()
+	return async
+}
+
+func (async *AsyncWriter) Write(bytes []byte) (int, error) {
+	return async.WriteLevel(logrus.InfoLevel, bytes)
+}
+
+func (async *AsyncWriter) WriteLevel(level Level, bytes []byte) (int, error) {
+	async.mutex.RLock()
+	defer async.mutex.RUnlock()
+	if async.closed {
+		return 0, fmt.Errorf("Async log writer `%s` has been closed.", async.name)
+	}
+	item := asyncItem{level: level, bytes: bytes}
+	if async.policy == AsyncPolicyBlock {
+		async.queued.Add(1)
+		async.queue <- item
+		return len(bytes), nil
+	}
+	select {
+	case async.queue <- item:
+		async.queued.Add(1)
+	default:
+		async.dropped.Add(1)
+		droppedCounter.Inc(async.name)
+	}
+	return len(bytes), nil
+}
+
+func (async *AsyncWriter) GetDropped() uint64 {
+	return async.dropped.Load()
+}
+
+// Flush waits until the entries queued so far have been written.
+func (async *AsyncWriter) Flush(ctx context.Context) error {
+	target := async.queued.Load()
+	ticker := time.NewTicker(asyncFlushInterval)
+	defer ticker.Stop()
+	for async.written.Load() < target {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Async log writer `%s` failed to flush %d entries: %w",
+				async.name, target-async.written.Load(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// Close writes the pending entries before closing the underlying writer.
+func (async *AsyncWriter) Close() error {
+	async.mutex.Lock()
+	if async.closed {
+		async.mutex.Unlock()
+		return nil
+	}
+	async.closed = true
+	close(async.queue)
+	async.mutex.Unlock()
+	<-async.done
+	return closeWriter(async.writer)
+}
+
+func (async *AsyncWriter) run() {
+	defer close(async.done)
+	for item := range async.queue {
+		var err error
+		if writer, ok := async.writer.(levelWriter); ok {
+			_, err = writer.WriteLevel(item.level, item.bytes)
+		} else {
+			_, err = async.writer.Write(item.bytes)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Logger failed to write output: %v\n", err)
+		}
+		async.written.Add(1)
+	}
+}
+
+// Flush waits until the async outputs of the logger have written their queued
+// entries, e.g. before the process exits.
+func Flush(ctx context.Context) error {
+	return flushLogger(ctx, GetLogger())
+}
+
+func FlushWithTimeout(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return Flush(ctx)
+}
+
+func flushLogger(ctx context.Context, logger *Logger) error {
+	dispatcherSwitch, ok := getDispatcherSwitch(logger)
+	if !ok {
+		return nil
+	}
+	return dispatcherSwitch.current.Load().flush(ctx)
+}
@@ -0,0 +1,32 @@
+package xblogger
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+type contextKey struct{}
+
+// IntoContext carries an entry in a context, so that the code receiving the
+// context logs with the same fields, e.g. the flow ID of a request.
+func IntoContext(ctx context.Context, entry *Entry) context.Context {
+	return context.WithValue(ctx, contextKey{}, entry)
+}
+
+// FromContext returns the entry carried by the context, or a bare entry of the
+// global logger when there is none.
+func FromContext(ctx context.Context) *Entry {
+	if entry, ok := LookupContext(ctx); ok {
+		return entry
+	}
+	return logrus.NewEntry(GetLogger())
+}
+
+func LookupContext(ctx context.Context) (*Entry, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	entry, ok := ctx.Value(contextKey{}).(*Entry)
+	return entry, ok && entry != nil
+}
@@ -0,0 +1,402 @@
+package xblogger
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/toolkit/xbslice"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xberror"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbjson"
+)
+
+const (
+	FormatJSON   = "json"
+	FormatText   = "text"
+	FormatLogfmt = "logfmt"
+	FormatECS    = "ecs"
+	FormatGCP    = "gcp"
+)
+
+var (
+	TraceIDKey = "traceID"
+	SpanIDKey  = "spanID"
+)
+
+// Formatter serializes a record into a single log line.
+type Formatter interface {
+	Format(record *Record) ([]byte, error)
+}
+
+type FormatterOptions struct {
+	Colored bool
+}
+
+type FormatterFactory = func(options *FormatterOptions) Formatter
+
+var formatterFactoryMap = map[string]FormatterFactory{
+	FormatJSON:   func(options *FormatterOptions) Formatter { return &jsonFormatter{} },
+	FormatText:   func(options *FormatterOptions) Formatter { return &textFormatter{colored: options.Colored} },
+	FormatLogfmt: func(options *FormatterOptions) Formatter { return &logfmtFormatter{} },
+	FormatECS:    func(options *FormatterOptions) Formatter { return &ecsFormatter{} },
+	FormatGCP:    func(options *FormatterOptions) Formatter { return &gcpFormatter{} },
+}
+
+func RegisterFormatter(name string, factory FormatterFactory) {
+	if _, ok := formatterFactoryMap[name]; ok {
+		panic(fmt.Sprintf("Duplicate log formatter `%s` is found.", name))
+	}
+	formatterFactoryMap[name] = factory
+}
+
+func NewFormatter(name string, options *FormatterOptions) (Formatter, error) {
+	factory, ok := formatterFactoryMap[name]
+	if !ok {
+		return nil, fmt.Errorf("Log formatter `%s` hasn't been registered.", name)
+	}
+	if options == nil {
+		options = &FormatterOptions{}
+	}
+	return factory(options), nil
+}
+
+// Record is an entry prepared once for every formatter: the caller is
+// resolved, the skip offset is dropped and the error is turned into its text,
+// with its cause tree under `ErrorCauseKey` and the log fields of custom errors.
+type Record struct {
+	Level   Level
+	Time    time.Time
+	Message string
+	Caller  *runtime.Frame
+	Fields  Fields
+}
+
+func (record *Record) GetLevelText() string {
+	level, _ := record.Level.MarshalText()
+	return string(level)
+}
+
+func (record *Record) GetCallerText() string {
+	if record.Caller == nil {
+		return ""
+	}
+	callerName := xbslice.Last(strings.Split(record.Caller.Function, "/"))
+	return fmt.Sprintf("%s:%d:%s", record.Caller.File, record.Caller.Line, callerName)
+}
+
+func newRecord(entry *Entry, caller *runtime.Frame) *Record {
+	record := (&recordBuilder{entry: entry, caller: caller}).
+		initialize().
+		setLevel().
+		setTime().
+		setMessage().
+		setCaller().
+		setFields().
+		build()
+	return record
+}
+
+const (
+	hookCallerFrameSkip      = 8
+	formatterCallerFrameSkip = 7
+	maxCallerFrameSize       = 1 << 5
+)
+
+// resolveCaller is called by the dispatcher, where the frames from the
+// logrus entry up to its hook or formatter are skipped, plus the offset of
+// `SkipKey`.
+func resolveCaller(entry *Entry, skip int) *runtime.Frame {
+	if value, ok := entry.Data[SkipKey]; ok {
+		if offset, ok := value.(int); ok {
+			skip += offset
+		} else {
+			panic(fmt.Sprintf("Skip offset `%d` must be of int type.", value))
+		}
+	}
+	return getCallerTracer().source(skip)
+}
+
+type recordBuilder struct {
+	entry  *Entry
+	caller *runtime.Frame
+	record *Record
+}
+
+func (builder *recordBuilder) build() *Record {
+	return builder.record
+}
+
+func (builder *recordBuilder) initialize() *recordBuilder {
+	builder.record = &Record{}
+	return builder
+}
+
+func (builder *recordBuilder) setLevel() *recordBuilder {
+	builder.record.Level = builder.entry.Level
+	return builder
+}
+
+func (builder *recordBuilder) setTime() *recordBuilder {
+	builder.record.Time = builder.entry.Time
+	return builder
+}
+
+func (builder *recordBuilder) setMessage() *recordBuilder {
+	builder.record.Message = builder.entry.Message
+	return builder
+}
+
+func (builder *recordBuilder) setCaller() *recordBuilder {
+	builder.record.Caller = builder.caller
+	return builder
+}
+
+func (builder *recordBuilder) setFields() *recordBuilder {
+	fields := make(Fields, len(builder.entry.Data))
+	maps.Copy(fields, builder.entry.Data)
+	delete(fields, SkipKey)
+	delete(fields, LevelKey)
+	if value, ok := fields[ErrorKey]; ok {
+		if err, ok := value.(error); ok {
+			fields[ErrorKey] = err.Error()
+			fields[ErrorCauseKey] = xberror.MakeCause(err)
+			builder.setErrorFields(fields, err)
+		}
+	}
+	builder.record.Fields = fields
+	return builder
+}
+
+func (builder *recordBuilder) setErrorFields(fields Fields, err error) {
+	if uerrs := xberror.Unwrap(err); uerrs != nil {
+		for _, uerr := range uerrs {
+			builder.setErrorFields(fields, uerr)
+		}
+	}
+	if cerr, ok := xberror.AsCustomError(err); ok {
+		maps.Copy(fields, cerr.LogFields())
+	}
+}
+
+func marshalLine(data any) ([]byte, error) {
+	bytes, err := xbjson.Marshal(data)
+	if err != nil {
+		return nil, xberror.Wrapf("Logger failed to JSON marshal data: `%#v`", []any{data}, err)
+	}
+	return append(bytes, '\n'), nil
+}
+
+func sortFieldKeys(fields Fields) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+type jsonFormatter struct{}
+
+func (formatter *jsonFormatter) Format(record *Record) ([]byte, error) {
+	data := Fields{
+		"level":   record.GetLevelText(),
+		"time":    record.Time.Format(time.DateTime + ".000000"),
+		"message": record.Message,
+		"fields":  record.Fields,
+	}
+	if caller := record.GetCallerText(); caller != "" {
+		data["caller"] = caller
+	}
+	return marshalLine(data)
+}
+
+const (
+	colorGray   = 90
+	colorRed    = 31
+	colorYellow = 33
+	colorBlue   = 36
+)
+
+type textFormatter struct {
+	colored bool
+}
+
+func (formatter *textFormatter) Format(record *Record) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	level := fmt.Sprintf("%-7s", strings.ToUpper(record.GetLevelText()))
+	if formatter.colored {
+		level = fmt.Sprintf("\x1b[%dm%s\x1b[0m", formatter.getLevelColor(record.Level), level)
+	}
+	fmt.Fprintf(buffer, "%s %s %s", record.Time.Format(time.DateTime+".000"), level, record.Message)
+	cause, _ := record.Fields[ErrorCauseKey].(*xberror.Cause)
+	for _, key := range sortFieldKeys(record.Fields) {
+		value := record.Fields[key]
+		if key == ErrorCauseKey && cause != nil {
+			continue
+		}
+		if formatter.colored {
+			fmt.Fprintf(buffer, " \x1b[%dm%s\x1b[0m=%s", colorGray, key, formatLogfmtValue(value))
+		} else {
+			fmt.Fprintf(buffer, " %s=%s", key, formatLogfmtValue(value))
+		}
+	}
+	if record.Caller != nil {
+		fmt.Fprintf(buffer, " (%s:%d)", record.Caller.File, record.Caller.Line)
+	}
+	if cause != nil && (len(cause.Stack) > 0 || len(cause.Causes) > 0) {
+		for _, line := range strings.Split(cause.Render(), "\n") {
+			fmt.Fprintf(buffer, "\n    %s", line)
+		}
+	}
+	buffer.WriteByte('\n')
+	return buffer.Bytes(), nil
+}
+
+func (formatter *textFormatter) getLevelColor(level Level) int {
+	switch level {
+	case logrus.TraceLevel, logrus.DebugLevel:
+		return colorGray
+	case logrus.WarnLevel:
+		return colorYellow
+	case logrus.ErrorLevel, logrus.FatalLevel, logrus.PanicLevel:
+		return colorRed
+	}
+	return colorBlue
+}
+
+type logfmtFormatter struct{}
+
+func (formatter *logfmtFormatter) Format(record *Record) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	fmt.Fprintf(buffer, "time=%s level=%s msg=%s",
+		record.Time.Format(time.RFC3339Nano), record.GetLevelText(), formatLogfmtValue(record.Message))
+	if caller := record.GetCallerText(); caller != "" {
+		fmt.Fprintf(buffer, " caller=%s", formatLogfmtValue(caller))
+	}
+	for _, key := range sortFieldKeys(record.Fields) {
+		fmt.Fprintf(buffer, " %s=%s", key, formatLogfmtValue(record.Fields[key]))
+	}
+	buffer.WriteByte('\n')
+	return buffer.Bytes(), nil
+}
+
+func formatLogfmtValue(value any) string {
+	var text string
+	switch value := value.(type) {
+	case string:
+		text = value
+	case error:
+		text = value.Error()
+	case fmt.Stringer:
+		text = value.String()
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		text = fmt.Sprint(value)
+	default:
+		if bytes, err := xbjson.Marshal(value); err == nil {
+			text = string(bytes)
+		} else {
+			text = fmt.Sprint(value)
+		}
+	}
+	if text == "" || strings.ContainsAny(text, " =\"\t\r\n\\") {
+		return strconv.Quote(text)
+	}
+	return text
+}
+
+// ecsFormatter follows the Elastic Common Schema, where the custom fields are
+// kept at the top level beside the ECS ones.
+type ecsFormatter struct{}
+
+const ecsVersion = "8.11.0"
+
+func (formatter *ecsFormatter) Format(record *Record) ([]byte, error) {
+	data := Fields{}
+	for key, value := range record.Fields {
+		switch key {
+		case ErrorKey:
+			data["error.message"] = value
+		case ErrorCauseKey:
+			if cause, ok := value.(*xberror.Cause); ok {
+				data["error.type"] = cause.Type
+				data["error.stack_trace"] = cause.Render()
+			} else {
+				data[key] = value
+			}
+		case TraceIDKey:
+			data["trace.id"] = value
+		case SpanIDKey:
+			data["span.id"] = value
+		default:
+			data[key] = value
+		}
+	}
+	data["@timestamp"] = record.Time.UTC().Format(time.RFC3339Nano)
+	data["log.level"] = record.GetLevelText()
+	data["message"] = record.Message
+	data["ecs.version"] = ecsVersion
+	data["service.name"] = xbcfg.GetServiceName()
+	data["service.version"] = xbcfg.GetServiceVersion()
+	data["service.environment"] = xbcfg.GetServiceEnvironment()
+	if record.Caller != nil {
+		data["log.origin.file.name"] = record.Caller.File
+		data["log.origin.file.line"] = record.Caller.Line
+		data["log.origin.function"] = record.Caller.Function
+	}
+	return marshalLine(data)
+}
+
+// gcpFormatter follows the Cloud Logging structured layout, whose special
+// fields are lifted out of the JSON payload by the logging agent.
+type gcpFormatter struct{}
+
+func (formatter *gcpFormatter) Format(record *Record) ([]byte, error) {
+	data := Fields{}
+	for key, value := range record.Fields {
+		switch key {
+		case TraceIDKey:
+			data["logging.googleapis.com/trace"] = value
+		case SpanIDKey:
+			data["logging.googleapis.com/spanId"] = value
+		default:
+			data[key] = value
+		}
+	}
+	data["severity"] = formatter.getSeverity(record.Level)
+	data["time"] = record.Time.UTC().Format(time.RFC3339Nano)
+	data["message"] = record.Message
+	if record.Caller != nil {
+		data["logging.googleapis.com/sourceLocation"] = Fields{
+			"file":     record.Caller.File,
+			"line":     strconv.Itoa(record.Caller.Line),
+			"function": record.Caller.Function,
+		}
+	}
+	return marshalLine(data)
+}
+
+func (formatter *gcpFormatter) getSeverity(level Level) string {
+	switch level {
+	case logrus.TraceLevel, logrus.DebugLevel:
+		return "DEBUG"
+	case logrus.InfoLevel:
+		return "INFO"
+	case logrus.WarnLevel:
+		return "WARNING"
+	case logrus.ErrorLevel:
+		return "ERROR"
+	case logrus.FatalLevel:
+		return "CRITICAL"
+	case logrus.PanicLevel:
+		return "ALERT"
+	}
+	return "DEFAULT"
+}
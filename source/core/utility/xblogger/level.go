@@ -0,0 +1,247 @@
+package xblogger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LevelKey carries the level of a request scoped entry, see `WithLevel`.
+var LevelKey = "#level"
+
+var mLevels = newLevels()
+
+// levels keeps the service level and the package overrides, while the logrus
+// level is kept at the most verbose of them, so that the entries are only
+// filtered by their own threshold once dispatched.
+type levels struct {
+	mutex         sync.RWMutex
+	logger        *Logger
+	level         Level
+	packageLevels map[string]Level
+	packageCache  sync.Map
+	levelLoggers  sync.Map
+}
+
+func newLevels() *levels {
+	return &levels{level: logrus.InfoLevel, packageLevels: map[string]Level{}}
+}
+
+func ParseLevel(text string) (Level, error) {
+	return logrus.ParseLevel(text)
+}
+
+// SetLevel changes the service level, which applies to the packages without
+// an override.
+func SetLevel(level Level) {
+	mLevels.mutex.Lock()
+	defer mLevels.mutex.Unlock()
+	mLevels.level = level
+	mLevels.update()
+}
+
+// SetPackageLevel overrides the level of the packages matched by the pattern,
+// which is either a package path, e.g. `source/module/xbgorm`, or its last
+// elements, e.g. `xbgorm`, and covers the subpackages. The longest matching
+// pattern wins.
+func SetPackageLevel(pattern string, level Level) {
+	mLevels.mutex.Lock()
+	defer mLevels.mutex.Unlock()
+	mLevels.packageLevels[strings.Trim(pattern, "/ ")] = level
+	mLevels.update()
+}
+
+func UnsetPackageLevel(pattern string) {
+	mLevels.mutex.Lock()
+	defer mLevels.mutex.Unlock()
+	delete(mLevels.packageLevels, strings.Trim(pattern, "/ "))
+	mLevels.update()
+}
+
+func GetPackageLevels() map[string]Level {
+	mLevels.mutex.RLock()
+	defer mLevels.mutex.RUnlock()
+	return maps.Clone(mLevels.packageLevels)
+}
+
+func setPackageLevels(packageLevels map[string]Level) {
+	mLevels.mutex.Lock()
+	defer mLevels.mutex.Unlock()
+	mLevels.packageLevels = packageLevels
+	mLevels.update()
+}
+
+// WithLevel makes an entry enabled down to the level, e.g. the one of a request
+// with a debug token, through a logger sharing the outputs of the logger but
+// not its level, so that the other entries are still dropped before they are
+// made.
+func WithLevel(level Level) *Entry {
+	return logrus.NewEntry(mLevels.getLevelLogger(GetLogger(), level)).WithField(LevelKey, level)
+}
+
+// ParsePackageLevels parses `pattern=level` items separated by commas, e.g.
+// `xbgorm=debug,source/utility=warn`.
+func ParsePackageLevels(text string) (map[string]Level, error) {
+	packageLevels := map[string]Level{}
+	for _, item := range strings.Split(text, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pattern, levelText, ok := strings.Cut(item, "=")
+		if !ok || strings.Trim(pattern, "/ ") == "" {
+			return nil, fmt.Errorf("Package level `%s` must be of `pattern=level` form.", item)
+		}
+		level, err := logrus.ParseLevel(strings.TrimSpace(levelText))
+		if err != nil {
+			return nil, fmt.Errorf("Package level `%s` has invalid level: %w", item, err)
+		}
+		packageLevels[strings.Trim(pattern, "/ ")] = level
+	}
+	return packageLevels, nil
+}
+
+func (levels *levels) update() {
+	levels.packageCache.Clear()
+	levels.levelLoggers.Clear()
+	if levels.logger == nil {
+		return
+	}
+	level := levels.level
+	for _, packageLevel := range levels.packageLevels {
+		level = max(level, packageLevel)
+	}
+	levels.logger.SetLevel(level)
+}
+
+// getLevelLogger copies the output, the formatter and the hooks of the logger
+// into a level logger, which is made again once the levels change.
+func (levels *levels) getLevelLogger(logger *Logger, level Level) *Logger {
+	if logger.IsLevelEnabled(level) {
+		return logger
+	}
+	if value, ok := levels.levelLoggers.Load(level); ok {
+		return value.(*Logger)
+	}
+	hooks := logrus.LevelHooks{}
+	for hookLevel, levelHooks := range logger.Hooks {
+		hooks[hookLevel] = slices.Clone(levelHooks)
+	}
+	levelLogger := logrus.New()
+	levelLogger.SetOutput(logger.Out)
+	levelLogger.SetFormatter(logger.Formatter)
+	levelLogger.ReplaceHooks(hooks)
+	levelLogger.SetLevel(level)
+	value, _ := levels.levelLoggers.LoadOrStore(level, levelLogger)
+	return value.(*Logger)
+}
+
+func (levels *levels) getLevel() Level {
+	levels.mutex.RLock()
+	defer levels.mutex.RUnlock()
+	return levels.level
+}
+
+func (levels *levels) isEnabled(entry *Entry, caller *runtime.Frame) bool {
+	if value, ok := entry.Data[LevelKey]; ok {
+		if level, ok := value.(Level); ok && entry.Level <= level {
+			return true
+		}
+	}
+	levels.mutex.RLock()
+	defer levels.mutex.RUnlock()
+	if entry.Level <= levels.level && len(levels.packageLevels) == 0 {
+		return true
+	}
+	return entry.Level <= levels.getCallerLevel(caller)
+}
+
+func (levels *levels) getCallerLevel(caller *runtime.Frame) Level {
+	if caller == nil || len(levels.packageLevels) == 0 {
+		return levels.level
+	}
+	path := getPackagePath(caller.Function)
+	if value, ok := levels.packageCache.Load(path); ok {
+		return value.(Level)
+	}
+	level, length := levels.level, -1
+	for pattern, packageLevel := range levels.packageLevels {
+		if len(pattern) > length && matchPackagePattern(path, pattern) {
+			level, length = packageLevel, len(pattern)
+		}
+	}
+	levels.packageCache.Store(path, level)
+	return level
+}
+
+// getPackagePath strips the receiver and function names, e.g. `a/b/pkg` of
+// `a/b/pkg.(*Type).Method`.
+func getPackagePath(function string) string {
+	index := strings.LastIndex(function, "/") + 1
+	if dot := strings.Index(function[index:], "."); dot >= 0 {
+		return function[:index+dot]
+	}
+	return function
+}
+
+func matchPackagePattern(path, pattern string) bool {
+	return path == pattern ||
+		strings.HasPrefix(path, pattern+"/") ||
+		strings.HasSuffix(path, "/"+pattern) ||
+		strings.Contains(path, "/"+pattern+"/")
+}
+
+// MakeDebugToken signs a level until the expire time in the form of
+// `{level}.{expireUnix}.{signature}`, to be sent by the debug header of a
+// request, e.g. `X-Log-Debug: debug.1767225600.3f2a...`. A token can be reused
+// until it expires, so its expire time should be short.
+func MakeDebugToken(secret string, level Level, expireTime time.Time) string {
+	payload := fmt.Sprintf("%s.%d", level, expireTime.Unix())
+	return fmt.Sprintf("%s.%s", payload, signDebugPayload(secret, payload))
+}
+
+func ParseDebugToken(secret, token string, now time.Time) (Level, error) {
+	if secret == "" {
+		return 0, fmt.Errorf("Debug token isn't enabled without a secret.")
+	}
+	index := strings.LastIndex(token, ".")
+	if index < 0 {
+		return 0, fmt.Errorf("Debug token is malformed.")
+	}
+	payload, signature := token[:index], token[index+1:]
+	if !hmac.Equal([]byte(signature), []byte(signDebugPayload(secret, payload))) {
+		return 0, fmt.Errorf("Debug token has invalid signature.")
+	}
+	levelText, expireText, ok := strings.Cut(payload, ".")
+	if !ok {
+		return 0, fmt.Errorf("Debug token is malformed.")
+	}
+	level, err := logrus.ParseLevel(levelText)
+	if err != nil {
+		return 0, fmt.Errorf("Debug token has invalid level: %w", err)
+	}
+	expireUnix, err := strconv.ParseInt(expireText, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Debug token has invalid expire time: %w", err)
+	}
+	if now.Unix() > expireUnix {
+		return 0, fmt.Errorf("Debug token has expired.")
+	}
+	return level, nil
+}
+
+func signDebugPayload(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
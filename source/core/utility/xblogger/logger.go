@@ -1,28 +1,26 @@
 package xblogger
 
 import (
+	"context"
 	"fmt"
-	"maps"
+	"io"
 	"reflect"
 	"runtime"
 	"strings"
-	"time"
 
 	"github.com/sirupsen/logrus"
 
 	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
-	"github.com/starryck/strk-tc-x-lib-go/source/core/toolkit/xbslice"
 	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbctnr"
-	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xberror"
-	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbjson"
 )
 
 var mLogger *Logger
 
 var (
-	SkipKey  = "#skip"
-	PanicKey = "panic"
-	ErrorKey = logrus.ErrorKey
+	SkipKey       = "#skip"
+	PanicKey      = "panic"
+	ErrorKey      = logrus.ErrorKey
+	ErrorCauseKey = "errorCause"
 )
 
 type (
@@ -43,7 +41,10 @@ func newLogger() *Logger {
 	logger := (&loggerBuilder{}).
 		initialize().
 		setSeverity().
-		setFormatter().
+		setOutputs().
+		watchSeverity().
+		watchOutputs().
+		setExitHandler().
 		build()
 	return logger
 }
@@ -112,8 +113,11 @@ func WithFields(fields Fields) *Entry {
 	return GetLogger().WithFields(fields)
 }
 
+// GetLevel returns the service level, while the logrus level may be more
+// verbose for the package overrides and the request scoped levels.
 func GetLevel() Level {
-	return GetLogger().GetLevel()
+	GetLogger()
+	return mLevels.getLevel()
 }
 
 func IsTraceLevel() bool {
@@ -149,7 +153,8 @@ func FormatPanic(value any, stack []byte) string {
 }
 
 type loggerBuilder struct {
-	logger *Logger
+	logger           *Logger
+	dispatcherSwitch *dispatcherSwitch
 }
 
 func (builder *loggerBuilder) build() *Logger {
@@ -162,120 +167,98 @@ func (builder *loggerBuilder) initialize() *loggerBuilder {
 }
 
 func (builder *loggerBuilder) setSeverity() *loggerBuilder {
-	if level, err := logrus.ParseLevel(xbcfg.GetServiceLogLevel()); err != nil {
+	level, err := logrus.ParseLevel(xbcfg.GetServiceLogLevel())
+	if err != nil {
 		panic(err)
-	} else {
-		builder.logger.SetLevel(level)
 	}
+	packageLevels, err := ParsePackageLevels(xbcfg.GetLogConfig().PackageLevels)
+	if err != nil {
+		panic(err)
+	}
+	mLevels.mutex.Lock()
+	defer mLevels.mutex.Unlock()
+	mLevels.logger = builder.logger
+	mLevels.level = level
+	mLevels.packageLevels = packageLevels
+	mLevels.update()
 	return builder
 }
 
-func (builder *loggerBuilder) setFormatter() *loggerBuilder {
-	builder.logger.SetFormatter(&jsonifier{})
-	return builder
-}
-
-type jsonifier struct{}
-
-func (jsonifier *jsonifier) Format(entry *Entry) ([]byte, error) {
-	bytes, err := (&jsonBuilder{entry: entry}).
-		initialize().
-		setLevel().
-		setTime().
-		setMessage().
-		setCaller().
-		setFields().
-		setBytes().
-		build()
-	return bytes, err
-}
-
-const (
-	minCallerFrameSkip = 7
-	maxCallerFrameSize = 1 << 5
-)
-
-type jsonBuilder struct {
-	entry *Entry
-	data  Fields
-	bytes []byte
-	err   error
-}
-
-func (builder *jsonBuilder) build() ([]byte, error) {
-	return builder.bytes, builder.err
-}
-
-func (builder *jsonBuilder) initialize() *jsonBuilder {
-	builder.data = make(Fields, 5)
-	return builder
-}
-
-func (builder *jsonBuilder) setLevel() *jsonBuilder {
-	level, _ := builder.entry.Level.MarshalText()
-	builder.data["level"] = string(level)
-	return builder
-}
-
-func (builder *jsonBuilder) setTime() *jsonBuilder {
-	builder.data["time"] = builder.entry.Time.Format(time.DateTime + ".000000")
+func (builder *loggerBuilder) setOutputs() *loggerBuilder {
+	dispatcher, err := newDispatcher(xbcfg.GetLogConfig())
+	if err != nil {
+		panic(err)
+	}
+	builder.dispatcherSwitch = newDispatcherSwitch(dispatcher)
+	builder.logger.SetOutput(io.Discard)
+	builder.logger.SetFormatter(builder.dispatcherSwitch)
+	builder.logger.AddHook(builder.dispatcherSwitch)
 	return builder
 }
 
-func (builder *jsonBuilder) setMessage() *jsonBuilder {
-	builder.data["message"] = builder.entry.Message
+func (builder *loggerBuilder) watchSeverity() *loggerBuilder {
+	logger := builder.logger
+	xbcfg.Watch(xbcfg.Config.GetServiceLogLevel, func(prev, next string) {
+		if level, err := logrus.ParseLevel(next); err != nil {
+			logger.WithError(err).Warnf("Logger failed to change level from `%s` to `%s`.", prev, next)
+		} else {
+			SetLevel(level)
+			logger.Infof("Logger changed level from `%s` to `%s`.", prev, next)
+		}
+	})
+	xbcfg.Watch(func(config xbcfg.Config) string {
+		return getLogConfig(config).PackageLevels
+	}, func(prev, next string) {
+		if packageLevels, err := ParsePackageLevels(next); err != nil {
+			logger.WithError(err).Warnf("Logger failed to change package levels from `%s` to `%s`.", prev, next)
+		} else {
+			setPackageLevels(packageLevels)
+			logger.Infof("Logger changed package levels from `%s` to `%s`.", prev, next)
+		}
+	})
 	return builder
 }
 
-func (builder *jsonBuilder) setCaller() *jsonBuilder {
-	skip := minCallerFrameSkip
-	if value, ok := builder.entry.Data[SkipKey]; ok {
-		if offset, ok := value.(int); ok {
-			skip += offset
-		} else {
-			panic(fmt.Sprintf("Skip offset `%d` must be of int type.", value))
+func (builder *loggerBuilder) watchOutputs() *loggerBuilder {
+	logger, dispatcherSwitch := builder.logger, builder.dispatcherSwitch
+	xbcfg.Subscribe(func(prev, next xbcfg.Config) {
+		prevConfig, nextConfig := getLogConfig(prev), getLogConfig(next)
+		if !isDispatcherChanged(prevConfig, nextConfig) {
+			return
 		}
-		delete(builder.entry.Data, SkipKey)
-	}
-	if caller := getCallerTracer().source(skip); caller != nil {
-		callerName := xbslice.Last(strings.Split(caller.Function, "/"))
-		builder.data["caller"] = fmt.Sprintf("%s:%d:%s", caller.File, caller.Line, callerName)
-	}
+		nextDispatcher, err := newDispatcher(nextConfig)
+		if err != nil {
+			logger.WithError(err).Warn("Logger failed to change outputs.")
+			return
+		}
+		dispatcherSwitch.swap(nextDispatcher).close()
+		logger.Infof("Logger changed outputs to `%s` in format `%s`.", nextConfig.Outputs, nextConfig.Format)
+	})
 	return builder
 }
 
-func (builder *jsonBuilder) setFields() *jsonBuilder {
-	fields := builder.entry.Data
-	if value, ok := fields[ErrorKey]; ok {
-		if err, ok := value.(error); ok {
-			fields[ErrorKey] = err.Error()
-			builder.setErrorFields(fields, err)
-		}
-	}
-	builder.data["fields"] = fields
+// setExitHandler flushes the async outputs before `Fatal` exits the process.
+func (builder *loggerBuilder) setExitHandler() *loggerBuilder {
+	logger := builder.logger
+	logrus.RegisterExitHandler(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultFlushTimeout)
+		defer cancel()
+		flushLogger(ctx, logger)
+	})
 	return builder
 }
 
-func (builder *jsonBuilder) setErrorFields(fields Fields, err error) {
-	if uerrs := xberror.Unwrap(err); uerrs != nil {
-		for _, uerr := range uerrs {
-			builder.setErrorFields(fields, uerr)
-		}
-	}
-	if cerr, ok := xberror.AsCustomError(err); ok {
-		maps.Copy(fields, cerr.LogFields())
-	}
+func getLogConfig(config xbcfg.Config) xbcfg.LogConfig {
+	return xbcfg.SectionOf[xbcfg.LogConfig](config)
 }
 
-func (builder *jsonBuilder) setBytes() *jsonBuilder {
-	bytes, err := xbjson.Marshal(builder.data)
-	if err != nil {
-		err = xberror.Wrapf("Logger failed to JSON marshal data: `%#v`", []any{builder.data}, err)
-	} else {
-		bytes = append(bytes, '\n')
+// isDispatcherChanged ignores the options which are watched on their own.
+func isDispatcherChanged(prev, next xbcfg.LogConfig) bool {
+	for _, config := range []*xbcfg.LogConfig{&prev, &next} {
+		config.PackageLevels = ""
+		config.DebugSecret = ""
 	}
-	builder.bytes, builder.err = bytes, err
-	return builder
+	return prev != next
 }
 
 var mCallerTracer *callerTracer
@@ -0,0 +1,613 @@
+package xblogger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/toolkit/xbvalue"
+)
+
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+	OutputSyslog = "syslog"
+)
+
+const (
+	defaultSyslogAddress = "/dev/log"
+	defaultFileMaxSize   = 100 << 20
+	defaultFileMaxBackup = 5
+)
+
+// OutputSpec is parsed from `kind[:target][@level]`, e.g. `stdout`,
+// `file:/var/log/app.log@warn` or `syslog:/dev/log@error`. An output only
+// receives the entries at or above its level, and never below the logger
+// level.
+type OutputSpec struct {
+	Kind   string
+	Target string
+	Level  Level
+}
+
+func ParseOutputSpecs(text string) ([]*OutputSpec, error) {
+	specs := []*OutputSpec{}
+	for _, item := range strings.Split(text, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		spec := &OutputSpec{Level: logrus.TraceLevel}
+		if index := strings.LastIndex(item, "@"); index >= 0 {
+			level, err := logrus.ParseLevel(item[index+1:])
+			if err != nil {
+				return nil, fmt.Errorf("Log output `%s` has invalid level: %w", item, err)
+			}
+			spec.Level, item = level, item[:index]
+		}
+		spec.Kind, spec.Target, _ = strings.Cut(item, ":")
+		switch spec.Kind {
+		case OutputStdout, OutputStderr:
+		case OutputFile:
+			if spec.Target == "" {
+				return nil, fmt.Errorf("Log output `%s` requires a file path.", item)
+			}
+		case OutputSyslog:
+			if spec.Target == "" {
+				spec.Target = defaultSyslogAddress
+			}
+		default:
+			return nil, fmt.Errorf("Log output kind `%s` isn't supported.", spec.Kind)
+		}
+		specs = append(specs, spec)
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("Log outputs must not be empty.")
+	}
+	return specs, nil
+}
+
+// Output writes the records at or above its level through its own formatter.
+type Output struct {
+	level     Level
+	writer    io.Writer
+	formatter Formatter
+}
+
+func NewOutput(level Level, writer io.Writer, formatter Formatter) *Output {
+	return &Output{level: level, writer: writer, formatter: formatter}
+}
+
+func (output *Output) write(record *Record) {
+	if record.Level > output.level {
+		return
+	}
+	bytes, err := output.formatter.Format(record)
+	if err == nil {
+		if writer, ok := output.writer.(levelWriter); ok {
+			_, err = writer.WriteLevel(record.Level, bytes)
+		} else {
+			_, err = output.writer.Write(bytes)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Logger failed to write output: %v\n", err)
+	}
+}
+
+func (output *Output) Flush(ctx context.Context) error {
+	if async, ok := output.writer.(*AsyncWriter); ok {
+		return async.Flush(ctx)
+	}
+	return nil
+}
+
+func (output *Output) Close() error {
+	return closeWriter(output.writer)
+}
+
+func closeWriter(writer io.Writer) error {
+	if closer, ok := writer.(io.Closer); ok && writer != os.Stdout && writer != os.Stderr {
+		return closer.Close()
+	}
+	return nil
+}
+
+type levelWriter interface {
+	WriteLevel(level Level, bytes []byte) (int, error)
+}
+
+type OutputsOptions struct {
+	Async       *bool
+	AsyncSize   *int
+	AsyncPolicy *string
+}
+
+// NewOutputs builds one output per spec, where the text format is colored on
+// terminals only. The writes go through an async writer per output when the
+// async option is on.
+func NewOutputs(format string, specs []*OutputSpec, options *OutputsOptions) ([]*Output, error) {
+	if options == nil {
+		options = &OutputsOptions{}
+	}
+	outputs := []*Output{}
+	for _, spec := range specs {
+		var writer io.Writer
+		switch spec.Kind {
+		case OutputStdout:
+			writer = os.Stdout
+		case OutputStderr:
+			writer = os.Stderr
+		case OutputFile:
+			file, err := NewRotatingFile(spec.Target, nil)
+			if err != nil {
+				closeOutputs(outputs)
+				return nil, err
+			}
+			writer = file
+		case OutputSyslog:
+			writer = NewSyslogWriter(spec.Target, nil)
+		}
+		formatter, err := NewFormatter(format, &FormatterOptions{Colored: isTerminal(writer)})
+		if err != nil {
+			closeWriter(writer)
+			closeOutputs(outputs)
+			return nil, err
+		}
+		if options.Async != nil && *options.Async {
+			writer = NewAsyncWriter(writer, &AsyncWriterOptions{
+				Name:   &spec.Kind,
+				Size:   options.AsyncSize,
+				Policy: options.AsyncPolicy,
+			})
+		}
+		outputs = append(outputs, NewOutput(spec.Level, writer, formatter))
+	}
+	return outputs, nil
+}
+
+func closeOutputs(outputs []*Output) error {
+	errs := []error{}
+	for _, output := range outputs {
+		errs = append(errs, output.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func isTerminal(writer io.Writer) bool {
+	file, ok := writer.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// dispatcher is the logrus hook fanning every entry out to the outputs, while
+// the logger itself writes to `io.Discard` unless its output is set. The
+// entries are redacted and then pass through the sampler when any of its
+// strategies is enabled, whose summaries are swept on a ticker until the
+// dispatcher is closed. The writes to the outputs are serialized by its mutex.
+type dispatcher struct {
+	mutex     sync.Mutex
+	outputs   []*Output
+	formatter Formatter
+	redactor  *Redactor
+	sampler   *Sampler
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func newDispatcher(config xbcfg.LogConfig) (*dispatcher, error) {
+	redactor, err := newConfigRedactor(config)
+	if err != nil {
+		return nil, err
+	}
+	sampler, err := newConfigSampler(config)
+	if err != nil {
+		return nil, err
+	}
+	formatter, err := NewFormatter(config.Format, nil)
+	if err != nil {
+		return nil, err
+	}
+	specs, err := ParseOutputSpecs(config.Outputs)
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := NewOutputs(config.Format, specs, &OutputsOptions{
+		Async:       xbvalue.Refer(config.Async),
+		AsyncSize:   xbvalue.Refer(config.AsyncSize),
+		AsyncPolicy: xbvalue.Refer(config.AsyncPolicy),
+	})
+	if err != nil {
+		return nil, err
+	}
+	dispatcher := &dispatcher{outputs: outputs, formatter: formatter, redactor: redactor, sampler: sampler}
+	if sampler != nil {
+		dispatcher.stop, dispatcher.done = make(chan struct{}), make(chan struct{})
+		go dispatcher.sweep()
+	}
+	return dispatcher, nil
+}
+
+func newConfigRedactor(config xbcfg.LogConfig) (*Redactor, error) {
+	if config.Redaction == RedactionNone {
+		return nil, nil
+	}
+	patterns := splitConfigList(config.RedactPatterns)
+	if err := CheckRedactPatterns(patterns); err != nil {
+		return nil, err
+	}
+	redactor := NewRedactor(&RedactorOptions{
+		Strict:   xbvalue.Refer(config.Redaction == RedactionStrict),
+		Fields:   splitConfigList(config.RedactFields),
+		Paths:    splitConfigList(config.RedactPaths),
+		Allows:   splitConfigList(config.RedactAllows),
+		Patterns: patterns,
+	})
+	return redactor, nil
+}
+
+func newConfigSampler(config xbcfg.LogConfig) (*Sampler, error) {
+	level, err := logrus.ParseLevel(config.SampleLevel)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := ParseSampleRules(config.SampleLevels)
+	if err != nil {
+		return nil, err
+	}
+	sampler := NewSampler(&SamplerOptions{
+		First:         xbvalue.Refer(config.SampleFirst),
+		Every:         xbvalue.Refer(config.SampleEvery),
+		Interval:      xbvalue.Refer(config.SampleInterval),
+		Level:         &level,
+		Levels:        levels,
+		RateLimit:     xbvalue.Refer(config.RateLimit),
+		RateBurst:     xbvalue.Refer(config.RateBurst),
+		DedupInterval: xbvalue.Refer(config.DedupInterval),
+	})
+	if !sampler.IsEnabled() {
+		return nil, nil
+	}
+	return sampler, nil
+}
+
+// dispatcherSwitch is the hook and the formatter of the logger and of its level
+// loggers, see `WithLevel`, so that a reload replaces the dispatcher of them
+// all.
+type dispatcherSwitch struct {
+	current atomic.Pointer[dispatcher]
+}
+
+func newDispatcherSwitch(dispatcher *dispatcher) *dispatcherSwitch {
+	dispatcherSwitch := &dispatcherSwitch{}
+	dispatcherSwitch.current.Store(dispatcher)
+	return dispatcherSwitch
+}
+
+// getDispatcherSwitch finds the dispatcher switch among the hooks of a logger.
+func getDispatcherSwitch(logger *Logger) (*dispatcherSwitch, bool) {
+	for _, hook := range logger.Hooks[logrus.PanicLevel] {
+		if dispatcherSwitch, ok := hook.(*dispatcherSwitch); ok {
+			return dispatcherSwitch, true
+		}
+	}
+	return nil, false
+}
+
+func (dispatcherSwitch *dispatcherSwitch) Levels() []Level {
+	return logrus.AllLevels
+}
+
+func (dispatcherSwitch *dispatcherSwitch) Fire(entry *Entry) error {
+	dispatcherSwitch.current.Load().fire(entry)
+	return nil
+}
+
+func (dispatcherSwitch *dispatcherSwitch) Format(entry *Entry) ([]byte, error) {
+	return dispatcherSwitch.current.Load().format(entry)
+}
+
+func (dispatcherSwitch *dispatcherSwitch) swap(dispatcher *dispatcher) *dispatcher {
+	return dispatcherSwitch.current.Swap(dispatcher)
+}
+
+func (dispatcher *dispatcher) fire(entry *Entry) {
+	caller := resolveCaller(entry, hookCallerFrameSkip)
+	if !mLevels.isEnabled(entry, caller) {
+		return
+	}
+	records := []*Record{dispatcher.newRecord(entry, caller)}
+	if dispatcher.sampler != nil {
+		records = dispatcher.sampler.Filter(records[0])
+	}
+	dispatcher.write(records)
+}
+
+// format serves an output set on the logger, which gets the redacted entries
+// but not the sampled ones, and skips the formatting while it is discarded.
+func (dispatcher *dispatcher) format(entry *Entry) ([]byte, error) {
+	if entry.Logger.Out == io.Discard {
+		return nil, nil
+	}
+	caller := resolveCaller(entry, formatterCallerFrameSkip)
+	if !mLevels.isEnabled(entry, caller) {
+		return nil, nil
+	}
+	return dispatcher.formatter.Format(dispatcher.newRecord(entry, caller))
+}
+
+func (dispatcher *dispatcher) newRecord(entry *Entry, caller *runtime.Frame) *Record {
+	record := newRecord(entry, caller)
+	if dispatcher.redactor != nil {
+		record.Fields = dispatcher.redactor.Redact(record.Fields)
+	}
+	return record
+}
+
+func (dispatcher *dispatcher) write(records []*Record) {
+	if len(records) == 0 {
+		return
+	}
+	dispatcher.mutex.Lock()
+	defer dispatcher.mutex.Unlock()
+	for _, record := range records {
+		for _, output := range dispatcher.outputs {
+			output.write(record)
+		}
+	}
+}
+
+func (dispatcher *dispatcher) sweep() {
+	defer close(dispatcher.done)
+	ticker := time.NewTicker(dispatcher.sampler.GetSweepInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			dispatcher.write(dispatcher.sampler.Sweep(now))
+		case <-dispatcher.stop:
+			return
+		}
+	}
+}
+
+func (dispatcher *dispatcher) flush(ctx context.Context) error {
+	if dispatcher.sampler != nil {
+		dispatcher.write(dispatcher.sampler.Drain(time.Now()))
+	}
+	errs := []error{}
+	for _, output := range dispatcher.outputs {
+		errs = append(errs, output.Flush(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// close writes the pending summaries before closing the outputs.
+func (dispatcher *dispatcher) close() error {
+	if dispatcher.sampler != nil {
+		close(dispatcher.stop)
+		<-dispatcher.done
+		dispatcher.write(dispatcher.sampler.Drain(time.Now()))
+	}
+	return closeOutputs(dispatcher.outputs)
+}
+
+func splitConfigList(text string) []string {
+	items := []string{}
+	for _, item := range strings.Split(text, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+type RotatingFileOptions struct {
+	MaxSize   *int64
+	MaxBackup *int
+}
+
+// RotatingFile renames `app.log` to `app.log.1` and so forth once it would
+// exceed its max size, keeping at most max backup files. A file failing to be
+// rotated or reopened is opened again on the next write.
+type RotatingFile struct {
+	mutex     sync.Mutex
+	path      string
+	file      *os.File
+	closed    bool
+	size      int64
+	maxSize   int64
+	maxBackup int
+}
+
+func NewRotatingFile(path string, options *RotatingFileOptions) (*RotatingFile, error) {
+	if options == nil {
+		options = &RotatingFileOptions{}
+	}
+	file := &RotatingFile{path: path, maxSize: defaultFileMaxSize, maxBackup: defaultFileMaxBackup}
+	if options.MaxSize != nil {
+		file.maxSize = *options.MaxSize
+	}
+	if options.MaxBackup != nil {
+		file.maxBackup = *options.MaxBackup
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("Log file `%s` failed to be created: %w", path, err)
+	}
+	if err := file.open(); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (file *RotatingFile) Write(bytes []byte) (int, error) {
+	file.mutex.Lock()
+	defer file.mutex.Unlock()
+	if file.closed {
+		return 0, fmt.Errorf("Log file `%s` has been closed.", file.path)
+	}
+	if file.file == nil {
+		if err := file.open(); err != nil {
+			return 0, err
+		}
+	}
+	if file.size > 0 && file.size+int64(len(bytes)) > file.maxSize {
+		if err := file.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	length, err := file.file.Write(bytes)
+	file.size += int64(length)
+	return length, err
+}
+
+func (file *RotatingFile) Close() error {
+	file.mutex.Lock()
+	defer file.mutex.Unlock()
+	file.closed = true
+	if file.file == nil {
+		return nil
+	}
+	err := file.file.Close()
+	file.file = nil
+	return err
+}
+
+func (file *RotatingFile) open() error {
+	handle, err := os.OpenFile(file.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("Log file `%s` failed to be opened: %w", file.path, err)
+	}
+	info, err := handle.Stat()
+	if err != nil {
+		handle.Close()
+		return fmt.Errorf("Log file `%s` failed to be inspected: %w", file.path, err)
+	}
+	file.file, file.size = handle, info.Size()
+	return nil
+}
+
+// rotate reopens the original file when it fails to be renamed or truncated,
+// so that the writes go on into it.
+func (file *RotatingFile) rotate() error {
+	err := file.file.Close()
+	file.file = nil
+	if err != nil {
+		return errors.Join(err, file.open())
+	}
+	if file.maxBackup > 0 {
+		for i := file.maxBackup - 1; i >= 1; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", file.path, i), fmt.Sprintf("%s.%d", file.path, i+1))
+		}
+		if err := os.Rename(file.path, file.path+".1"); err != nil {
+			err = fmt.Errorf("Log file `%s` failed to be rotated: %w", file.path, err)
+			return errors.Join(err, file.open())
+		}
+	} else if err := os.Truncate(file.path, 0); err != nil {
+		err = fmt.Errorf("Log file `%s` failed to be truncated: %w", file.path, err)
+		return errors.Join(err, file.open())
+	}
+	return file.open()
+}
+
+type SyslogWriterOptions struct {
+	Tag *string
+}
+
+// SyslogWriter sends RFC 3164 messages to a local syslog socket, connecting
+// lazily and reconnecting once when a write fails.
+type SyslogWriter struct {
+	mutex   sync.Mutex
+	address string
+	tag     string
+	conn    net.Conn
+}
+
+func NewSyslogWriter(address string, options *SyslogWriterOptions) *SyslogWriter {
+	if options == nil {
+		options = &SyslogWriterOptions{}
+	}
+	writer := &SyslogWriter{address: address, tag: filepath.Base(os.Args[0])}
+	if options.Tag != nil {
+		writer.tag = *options.Tag
+	}
+	return writer
+}
+
+func (writer *SyslogWriter) Write(bytes []byte) (int, error) {
+	return writer.WriteLevel(logrus.InfoLevel, bytes)
+}
+
+func (writer *SyslogWriter) WriteLevel(level Level, bytes []byte) (int, error) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+	message := fmt.Sprintf("<%d>%s %s[%d]: %s", syslogFacilityUser|getSyslogSeverity(level),
+		time.Now().Format(time.Stamp), writer.tag, os.Getpid(), strings.TrimRight(string(bytes), "\n"))
+	var err error
+	for range 2 {
+		if writer.conn == nil {
+			if writer.conn, err = writer.dial(); err != nil {
+				continue
+			}
+		}
+		if _, err = writer.conn.Write([]byte(message)); err == nil {
+			return len(bytes), nil
+		}
+		writer.conn.Close()
+		writer.conn = nil
+	}
+	return 0, fmt.Errorf("Syslog `%s` failed to be written: %w", writer.address, err)
+}
+
+func (writer *SyslogWriter) Close() error {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+	if writer.conn == nil {
+		return nil
+	}
+	err := writer.conn.Close()
+	writer.conn = nil
+	return err
+}
+
+func (writer *SyslogWriter) dial() (net.Conn, error) {
+	conn, err := net.Dial("unixgram", writer.address)
+	if err != nil {
+		conn, err = net.Dial("unix", writer.address)
+	}
+	return conn, err
+}
+
+const syslogFacilityUser = 1 << 3
+
+func getSyslogSeverity(level Level) int {
+	switch level {
+	case logrus.PanicLevel:
+		return 1
+	case logrus.FatalLevel:
+		return 2
+	case logrus.ErrorLevel:
+		return 3
+	case logrus.WarnLevel:
+		return 4
+	case logrus.InfoLevel:
+		return 6
+	}
+	return 7
+}
@@ -0,0 +1,408 @@
+package xblogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xberror"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbjson"
+)
+
+const (
+	RedactionNone   = "none"
+	RedactionRules  = "rules"
+	RedactionStrict = "strict"
+)
+
+const RedactedValue = "[REDACTED]"
+
+var defaultRedactedFields = []string{
+	"password", "passwd", "pwd", "secret", "token", "accessToken", "refreshToken", "idToken",
+	"apiKey", "apiSecret", "clientSecret", "privateKey", "authorization", "proxyAuthorization",
+	"cookie", "setCookie", "xApiKey", "xAuthToken", "creditCard", "cardNumber", "cvv", "cvc", "ssn",
+}
+
+// The content fields hold raw bodies, whose JSON is redacted as its decoded
+// value.
+var defaultContentFields = []string{"requestContent", "requestBody"}
+
+var (
+	mFieldRedactor     atomic.Pointer[Redactor]
+	mFieldRedactorOnce sync.Once
+)
+
+var defaultAllowedFields = []string{
+	"flowID", "flowTrails", TraceIDKey, SpanIDKey, ErrorKey, ErrorCauseKey, PanicKey, "RequestID",
+	"requestURI", "requestMethod", "requestHandler", "responseTime", "responseSize", "responseStatus",
+	"script", "dryRun", "daemon", "duration", SuppressedMessageKey, SuppressedCountKey, SuppressedReasonKey,
+}
+
+// RedactPattern masks the matches of its regexp within string values, except
+// the ones its check rejects, e.g. the digits failing the Luhn checksum.
+type RedactPattern struct {
+	Regexp *regexp.Regexp
+	Check  func(match string) bool
+}
+
+var redactPatternMap = map[string]*RedactPattern{
+	"email":  {Regexp: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+	"card":   {Regexp: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), Check: checkLuhn},
+	"bearer": {Regexp: regexp.MustCompile(`(?i)\b(?:bearer|basic)\s+[A-Za-z0-9._~+/-]+=*`)},
+	"jwt":    {Regexp: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)},
+}
+
+func RegisterRedactPattern(name string, pattern *RedactPattern) {
+	if _, ok := redactPatternMap[name]; ok {
+		panic(fmt.Sprintf("Duplicate redact pattern `%s` is found.", name))
+	}
+	redactPatternMap[name] = pattern
+}
+
+// RedactorOptions are added to the default rules. A field is matched by its
+// name regardless of case and separators, e.g. `api_key` matches `X-API-Key`,
+// or by its path from the record fields, e.g. `requestContent.user.email`,
+// where `*` matches any key or index. In strict mode, every value is redacted
+// unless itself or one of its parents is allowed. The registered patterns are
+// only scanned for when they are named, e.g. `email` or `card`.
+type RedactorOptions struct {
+	Strict   *bool
+	Fields   []string
+	Paths    []string
+	Allows   []string
+	Patterns []string
+}
+
+// Redactor masks the sensitive values of the record fields before they are
+// serialized, including the ones nested in maps, header maps and the JSON of
+// the content fields, e.g. the request content. The other values are kept as
+// they are, unless in strict mode, where structs are walked as their JSON.
+type Redactor struct {
+	strict        bool
+	deniedNameSet map[string]bool
+	deniedPaths   [][]string
+	allowedSet    map[string]bool
+	allowedPaths  [][]string
+	contentSet    map[string]bool
+	patterns      []*RedactPattern
+}
+
+func NewRedactor(options *RedactorOptions) *Redactor {
+	redactor := (&redactorBuilder{options: options}).
+		initialize().
+		setStrict().
+		setDenials().
+		setAllowances().
+		setPatterns().
+		build()
+	return redactor
+}
+
+func (redactor *Redactor) Redact(fields Fields) Fields {
+	return Fields(redactor.redactMap(fields, nil, !redactor.strict))
+}
+
+// IsSensitiveField tells whether the values of a field name are redacted by the
+// default or the configured fields, e.g. to leave them out of a response.
+func IsSensitiveField(name string) bool {
+	return getFieldRedactor().deniedNameSet[normalizeRedactName(name)]
+}
+
+// getFieldRedactor builds the redactor of the configured fields once, and
+// again only when they change.
+func getFieldRedactor() *Redactor {
+	mFieldRedactorOnce.Do(func() {
+		mFieldRedactor.Store(newFieldRedactor(xbcfg.GetLogConfig().RedactFields))
+		xbcfg.Watch(func(config xbcfg.Config) string {
+			return getLogConfig(config).RedactFields
+		}, func(prev, next string) {
+			mFieldRedactor.Store(newFieldRedactor(next))
+		})
+	})
+	return mFieldRedactor.Load()
+}
+
+func newFieldRedactor(fields string) *Redactor {
+	return NewRedactor(&RedactorOptions{Fields: splitConfigList(fields)})
+}
+
+func (redactor *Redactor) redactMap(values map[string]any, path []string, allowed bool) map[string]any {
+	result := make(map[string]any, len(values))
+	for key, value := range values {
+		subpath := append(slices.Clip(path), key)
+		if redactor.isDenied(key, subpath) {
+			result[key] = RedactedValue
+			continue
+		}
+		result[key] = redactor.redactValue(value, subpath, allowed || redactor.isAllowed(key, subpath))
+	}
+	return result
+}
+
+func (redactor *Redactor) redactSlice(values []any, path []string, allowed bool) []any {
+	result := make([]any, len(values))
+	for i, value := range values {
+		subpath := append(slices.Clip(path), strconv.Itoa(i))
+		if matchRedactPaths(redactor.deniedPaths, subpath) {
+			result[i] = RedactedValue
+			continue
+		}
+		result[i] = redactor.redactValue(value, subpath, allowed || matchRedactPaths(redactor.allowedPaths, subpath))
+	}
+	return result
+}
+
+func (redactor *Redactor) redactHeader(header http.Header, path []string) http.Header {
+	result := make(http.Header, len(header))
+	for key, values := range header {
+		if redactor.isDenied(key, append(slices.Clip(path), key)) {
+			result[key] = []string{RedactedValue}
+		} else {
+			result[key] = values
+		}
+	}
+	return result
+}
+
+func (redactor *Redactor) redactValue(value any, path []string, allowed bool) any {
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return redactor.redactString(value, path, allowed)
+	case *xberror.Cause:
+		if !redactor.strict && len(redactor.patterns) == 0 {
+			return value
+		}
+		return redactor.redactCause(value, path, allowed)
+	case error:
+		if !redactor.strict && len(redactor.patterns) == 0 {
+			return value
+		}
+		return redactor.redactString(value.Error(), path, allowed)
+	case bool, json.Number, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		if !allowed {
+			return RedactedValue
+		}
+		return value
+	case Fields:
+		return redactor.redactMap(value, path, allowed)
+	case map[string]any:
+		return redactor.redactMap(value, path, allowed)
+	case []any:
+		return redactor.redactSlice(value, path, allowed)
+	case http.Header:
+		if !redactor.strict {
+			return redactor.redactHeader(value, path)
+		}
+	case map[string][]string:
+		if !redactor.strict {
+			return map[string][]string(redactor.redactHeader(value, path))
+		}
+	}
+	if !redactor.strict {
+		return value
+	}
+	data, err := xbjson.Marshal(value)
+	if err != nil {
+		return redactor.redactString(fmt.Sprint(value), path, allowed)
+	}
+	decoded, err := decodeRedactJSON(data)
+	if err != nil {
+		return redactor.redactString(string(data), path, allowed)
+	}
+	return redactor.redactValue(decoded, path, allowed)
+}
+
+// redactCause keeps the tree of an error, so that the formatters may still
+// render it, and redacts the message of each node.
+func (redactor *Redactor) redactCause(cause *xberror.Cause, path []string, allowed bool) *xberror.Cause {
+	result := *cause
+	result.Message = fmt.Sprint(redactor.redactString(cause.Message, path, allowed))
+	result.Causes = make([]*xberror.Cause, len(cause.Causes))
+	for i, subcause := range cause.Causes {
+		result.Causes[i] = redactor.redactCause(subcause, path, allowed)
+	}
+	return &result
+}
+
+// redactString redacts the JSON of a content field as its decoded value, so
+// that the rules reach into raw bodies, and masks the pattern matches of the
+// other strings.
+func (redactor *Redactor) redactString(text string, path []string, allowed bool) any {
+	if len(path) == 1 && redactor.contentSet[normalizeRedactName(path[0])] {
+		if trimmed := strings.TrimSpace(text); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+			if decoded, err := decodeRedactJSON([]byte(trimmed)); err == nil {
+				if data, err := xbjson.Marshal(redactor.redactValue(decoded, path, allowed)); err == nil {
+					return string(data)
+				}
+			}
+		}
+	}
+	if !allowed {
+		return RedactedValue
+	}
+	for _, pattern := range redactor.patterns {
+		text = pattern.Regexp.ReplaceAllStringFunc(text, func(match string) string {
+			if pattern.Check != nil && !pattern.Check(match) {
+				return match
+			}
+			return RedactedValue
+		})
+	}
+	return text
+}
+
+func (redactor *Redactor) isDenied(key string, path []string) bool {
+	return redactor.deniedNameSet[normalizeRedactName(key)] || matchRedactPaths(redactor.deniedPaths, path)
+}
+
+func (redactor *Redactor) isAllowed(key string, path []string) bool {
+	return redactor.allowedSet[normalizeRedactName(key)] || matchRedactPaths(redactor.allowedPaths, path)
+}
+
+func decodeRedactJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("JSON has trailing data.")
+	}
+	return value, nil
+}
+
+func normalizeRedactName(name string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", "_", "", " ", "").Replace(name))
+}
+
+func isRedactPath(rule string) bool {
+	return strings.ContainsAny(rule, ".*")
+}
+
+func parseRedactPath(rule string) []string {
+	return strings.Split(strings.TrimPrefix(rule, "$."), ".")
+}
+
+func matchRedactPaths(rules [][]string, path []string) bool {
+	for _, rule := range rules {
+		if len(rule) != len(path) {
+			continue
+		}
+		matched := true
+		for i, segment := range rule {
+			if segment != "*" && segment != path[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func checkLuhn(match string) bool {
+	digits := []int{}
+	for _, char := range match {
+		if char >= '0' && char <= '9' {
+			digits = append(digits, int(char-'0'))
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := range digits {
+		digit := digits[len(digits)-1-i]
+		if i%2 == 1 {
+			if digit *= 2; digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	return sum%10 == 0
+}
+
+type redactorBuilder struct {
+	options  *RedactorOptions
+	redactor *Redactor
+}
+
+func (builder *redactorBuilder) build() *Redactor {
+	return builder.redactor
+}
+
+func (builder *redactorBuilder) initialize() *redactorBuilder {
+	if builder.options == nil {
+		builder.options = &RedactorOptions{}
+	}
+	builder.redactor = &Redactor{deniedNameSet: map[string]bool{}, allowedSet: map[string]bool{}, contentSet: map[string]bool{}}
+	for _, name := range defaultContentFields {
+		builder.redactor.contentSet[normalizeRedactName(name)] = true
+	}
+	return builder
+}
+
+func (builder *redactorBuilder) setStrict() *redactorBuilder {
+	if strict := builder.options.Strict; strict != nil {
+		builder.redactor.strict = *strict
+	}
+	return builder
+}
+
+func (builder *redactorBuilder) setDenials() *redactorBuilder {
+	redactor := builder.redactor
+	for _, name := range append(slices.Clip(defaultRedactedFields), builder.options.Fields...) {
+		redactor.deniedNameSet[normalizeRedactName(name)] = true
+	}
+	for _, rule := range builder.options.Paths {
+		redactor.deniedPaths = append(redactor.deniedPaths, parseRedactPath(rule))
+	}
+	return builder
+}
+
+func (builder *redactorBuilder) setAllowances() *redactorBuilder {
+	redactor := builder.redactor
+	for _, rule := range append(slices.Clip(defaultAllowedFields), builder.options.Allows...) {
+		if isRedactPath(rule) {
+			redactor.allowedPaths = append(redactor.allowedPaths, parseRedactPath(rule))
+		} else {
+			redactor.allowedSet[normalizeRedactName(rule)] = true
+		}
+	}
+	return builder
+}
+
+func (builder *redactorBuilder) setPatterns() *redactorBuilder {
+	for _, name := range builder.options.Patterns {
+		pattern, ok := redactPatternMap[name]
+		if !ok {
+			panic(fmt.Sprintf("Redact pattern `%s` hasn't been registered.", name))
+		}
+		builder.redactor.patterns = append(builder.redactor.patterns, pattern)
+	}
+	return builder
+}
+
+// CheckRedactPatterns reports the pattern names which haven't been registered.
+func CheckRedactPatterns(names []string) error {
+	for _, name := range names {
+		if _, ok := redactPatternMap[name]; !ok {
+			return fmt.Errorf("Redact pattern `%s` hasn't been registered.", name)
+		}
+	}
+	return nil
+}
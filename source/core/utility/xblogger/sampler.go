@@ -0,0 +1,402 @@
+package xblogger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	SuppressedMessageKey = "suppressedMessage"
+	SuppressedCountKey   = "suppressedCount"
+	SuppressedReasonKey  = "suppressedReason"
+)
+
+const (
+	suppressedReasonSample = "sample"
+	suppressedReasonDedup  = "dedup"
+	suppressedReasonRate   = "rate"
+)
+
+const (
+	defaultSampleEvery    = 100
+	defaultSampleInterval = time.Second
+	defaultSampleLevel    = logrus.InfoLevel
+)
+
+// SamplerOptions disable every strategy left at zero:
+//   - sampling passes the first entries of a message in every interval, then
+//     one out of every so many, for the levels at or below the sample level,
+//     unless a level has its own rule, e.g. `debug=1/1000` or `warn=0`;
+//   - rate limiting passes a burst of entries, then at most the rate limit per
+//     second across all messages;
+//   - deduplication passes the first of the identical entries, i.e. the same
+//     level, message and error, in every dedup interval.
+//
+// The suppressed entries are counted and summarized once their interval ends,
+// see `Sweep`, or when the logger gets flushed or closed, see `Drain`.
+type SamplerOptions struct {
+	First         *int
+	Every         *int
+	Interval      *time.Duration
+	Level         *Level
+	Levels        map[Level]SampleRule
+	RateLimit     *int
+	RateBurst     *int
+	DedupInterval *time.Duration
+}
+
+// SampleRule passes the first entries of a message in every interval, then one
+// out of every so many. A rule without any first entries doesn't sample.
+type SampleRule struct {
+	First int
+	Every int
+}
+
+// ParseSampleRules parses the per-level rules of `level=first[/every]` items,
+// e.g. `debug=1/1000,info=10/100,warn=0`.
+func ParseSampleRules(text string) (map[Level]SampleRule, error) {
+	rules := map[Level]SampleRule{}
+	for _, item := range strings.Split(text, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		levelText, ruleText, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("Sample rule `%s` must be of `level=first[/every]` form.", item)
+		}
+		level, err := logrus.ParseLevel(strings.TrimSpace(levelText))
+		if err != nil {
+			return nil, fmt.Errorf("Sample rule `%s` has invalid level: %w", item, err)
+		}
+		rule := SampleRule{}
+		firstText, everyText, hasEvery := strings.Cut(ruleText, "/")
+		if rule.First, err = strconv.Atoi(strings.TrimSpace(firstText)); err != nil || rule.First < 0 {
+			return nil, fmt.Errorf("Sample rule `%s` has invalid first count.", item)
+		}
+		if hasEvery {
+			if rule.Every, err = strconv.Atoi(strings.TrimSpace(everyText)); err != nil || rule.Every < 0 {
+				return nil, fmt.Errorf("Sample rule `%s` has invalid every count.", item)
+			}
+		}
+		rules[level] = rule
+	}
+	return rules, nil
+}
+
+type Sampler struct {
+	mutex         sync.Mutex
+	first         int
+	every         int
+	interval      time.Duration
+	level         Level
+	levels        map[Level]SampleRule
+	rateLimit     float64
+	rateBurst     float64
+	rateTokens    float64
+	rateTime      time.Time
+	rateDropped   int
+	dedupInterval time.Duration
+	sampleWindows map[string]*sampleWindow
+	sampleTime    time.Time
+	dedupWindows  map[string]*sampleWindow
+	dedupTime     time.Time
+}
+
+type sampleWindow struct {
+	level     Level
+	message   string
+	startTime time.Time
+	count     int
+	dropped   int
+}
+
+func NewSampler(options *SamplerOptions) *Sampler {
+	sampler := (&samplerBuilder{options: options}).
+		initialize().
+		setSampling().
+		setRateLimit().
+		setDedup().
+		build()
+	return sampler
+}
+
+func (sampler *Sampler) IsEnabled() bool {
+	return sampler.isSampling() || sampler.rateLimit > 0 || sampler.dedupInterval > 0
+}
+
+// GetSweepInterval is the shortest interval of the enabled strategies, at which
+// the ended intervals are expected to be swept.
+func (sampler *Sampler) GetSweepInterval() time.Duration {
+	interval := time.Second
+	if sampler.isSampling() {
+		interval = min(interval, sampler.interval)
+	}
+	if sampler.dedupInterval > 0 {
+		interval = min(interval, sampler.dedupInterval)
+	}
+	return interval
+}
+
+// Sweep returns the summaries of the ended intervals, so that they are written
+// even when no other entry is logged afterwards.
+func (sampler *Sampler) Sweep(now time.Time) []*Record {
+	sampler.mutex.Lock()
+	defer sampler.mutex.Unlock()
+	records := sampler.sweep(now)
+	return appendRecord(records, sampler.summarizeRate(now))
+}
+
+// Drain ends every interval and returns their summaries, e.g. before the
+// outputs get flushed or closed.
+func (sampler *Sampler) Drain(now time.Time) []*Record {
+	sampler.mutex.Lock()
+	defer sampler.mutex.Unlock()
+	records := sweepWindows(sampler.sampleWindows, now, 0, suppressedReasonSample)
+	records = append(records, sweepWindows(sampler.dedupWindows, now, 0, suppressedReasonDedup)...)
+	return appendRecord(records, sampler.summarizeRate(now))
+}
+
+func (sampler *Sampler) isSampling() bool {
+	if sampler.first > 0 {
+		return true
+	}
+	for _, rule := range sampler.levels {
+		if rule.First > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (sampler *Sampler) getRule(level Level) (SampleRule, bool) {
+	if rule, ok := sampler.levels[level]; ok {
+		return rule, rule.First > 0
+	}
+	if sampler.first > 0 && level >= sampler.level {
+		return SampleRule{First: sampler.first, Every: sampler.every}, true
+	}
+	return SampleRule{}, false
+}
+
+// Filter returns the records to be written in place of the given one, which
+// are the summaries of the ended intervals followed by the record itself
+// unless it is suppressed. Fatal and panic records are never suppressed.
+func (sampler *Sampler) Filter(record *Record) []*Record {
+	sampler.mutex.Lock()
+	defer sampler.mutex.Unlock()
+	now := record.Time
+	records := sampler.sweep(now)
+	if record.Level <= logrus.FatalLevel {
+		return append(records, record)
+	}
+	if rule, ok := sampler.getRule(record.Level); ok {
+		summary, ok := sampler.sample(record, now, rule)
+		records = appendRecord(records, summary)
+		if !ok {
+			return records
+		}
+	}
+	if sampler.dedupInterval > 0 {
+		summary, ok := sampler.dedup(record, now)
+		records = appendRecord(records, summary)
+		if !ok {
+			return records
+		}
+	}
+	if sampler.rateLimit > 0 {
+		summary, ok := sampler.limit(now)
+		records = appendRecord(records, summary)
+		if !ok {
+			return records
+		}
+	}
+	return append(records, record)
+}
+
+func (sampler *Sampler) sample(record *Record, now time.Time, rule SampleRule) (*Record, bool) {
+	key := fmt.Sprintf("%s|%s", record.Level, record.Message)
+	window, summary := renewWindow(sampler.sampleWindows, key, record, now, sampler.interval, suppressedReasonSample)
+	window.count++
+	if window.count <= rule.First || (rule.Every > 0 && (window.count-rule.First)%rule.Every == 0) {
+		return summary, true
+	}
+	window.dropped++
+	return summary, false
+}
+
+func (sampler *Sampler) dedup(record *Record, now time.Time) (*Record, bool) {
+	key := fmt.Sprintf("%s|%s|%s", record.Level, record.Message, formatLogfmtValue(record.Fields[ErrorKey]))
+	window, summary := renewWindow(sampler.dedupWindows, key, record, now, sampler.dedupInterval, suppressedReasonDedup)
+	window.count++
+	if window.count == 1 {
+		return summary, true
+	}
+	window.dropped++
+	return summary, false
+}
+
+func (sampler *Sampler) limit(now time.Time) (*Record, bool) {
+	if sampler.rateTime.IsZero() {
+		sampler.rateTokens = sampler.rateBurst
+	} else if elapsed := now.Sub(sampler.rateTime).Seconds(); elapsed > 0 {
+		sampler.rateTokens = min(sampler.rateBurst, sampler.rateTokens+elapsed*sampler.rateLimit)
+	}
+	if now.After(sampler.rateTime) {
+		sampler.rateTime = now
+	}
+	if sampler.rateTokens < 1 {
+		sampler.rateDropped++
+		return nil, false
+	}
+	sampler.rateTokens--
+	return sampler.summarizeRate(now), true
+}
+
+func (sampler *Sampler) summarizeRate(now time.Time) *Record {
+	if sampler.rateDropped == 0 {
+		return nil
+	}
+	summary := &Record{
+		Level:   logrus.WarnLevel,
+		Time:    now,
+		Message: fmt.Sprintf("Logger suppressed %d messages over rate limit.", sampler.rateDropped),
+		Fields: Fields{
+			SuppressedCountKey:  sampler.rateDropped,
+			SuppressedReasonKey: suppressedReasonRate,
+		},
+	}
+	sampler.rateDropped = 0
+	return summary
+}
+
+// sweep ends the expired windows of the messages not logged again, so that
+// their summaries aren't held back and the windows don't pile up.
+func (sampler *Sampler) sweep(now time.Time) []*Record {
+	records := []*Record{}
+	if sampler.isSampling() && now.Sub(sampler.sampleTime) >= sampler.interval {
+		records = append(records, sweepWindows(sampler.sampleWindows, now, sampler.interval, suppressedReasonSample)...)
+		sampler.sampleTime = now
+	}
+	if sampler.dedupInterval > 0 && now.Sub(sampler.dedupTime) >= sampler.dedupInterval {
+		records = append(records, sweepWindows(sampler.dedupWindows, now, sampler.dedupInterval, suppressedReasonDedup)...)
+		sampler.dedupTime = now
+	}
+	return records
+}
+
+func renewWindow(windows map[string]*sampleWindow, key string, record *Record, now time.Time,
+	interval time.Duration, reason string) (*sampleWindow, *Record) {
+	window, ok := windows[key]
+	if ok && now.Sub(window.startTime) < interval {
+		return window, nil
+	}
+	var summary *Record
+	if ok {
+		summary = window.summarize(now, reason)
+	}
+	window = &sampleWindow{level: record.Level, message: record.Message, startTime: now}
+	windows[key] = window
+	return window, summary
+}
+
+func sweepWindows(windows map[string]*sampleWindow, now time.Time, interval time.Duration, reason string) []*Record {
+	records := []*Record{}
+	for key, window := range windows {
+		if now.Sub(window.startTime) < interval {
+			continue
+		}
+		records = appendRecord(records, window.summarize(now, reason))
+		delete(windows, key)
+	}
+	return records
+}
+
+func (window *sampleWindow) summarize(now time.Time, reason string) *Record {
+	if window.dropped == 0 {
+		return nil
+	}
+	return &Record{
+		Level:   window.level,
+		Time:    now,
+		Message: fmt.Sprintf("Logger suppressed %d similar messages.", window.dropped),
+		Fields: Fields{
+			SuppressedMessageKey: window.message,
+			SuppressedCountKey:   window.dropped,
+			SuppressedReasonKey:  reason,
+		},
+	}
+}
+
+func appendRecord(records []*Record, record *Record) []*Record {
+	if record == nil {
+		return records
+	}
+	return append(records, record)
+}
+
+type samplerBuilder struct {
+	options *SamplerOptions
+	sampler *Sampler
+}
+
+func (builder *samplerBuilder) build() *Sampler {
+	return builder.sampler
+}
+
+func (builder *samplerBuilder) initialize() *samplerBuilder {
+	if builder.options == nil {
+		builder.options = &SamplerOptions{}
+	}
+	builder.sampler = &Sampler{
+		sampleWindows: map[string]*sampleWindow{},
+		dedupWindows:  map[string]*sampleWindow{},
+	}
+	return builder
+}
+
+func (builder *samplerBuilder) setSampling() *samplerBuilder {
+	options, sampler := builder.options, builder.sampler
+	sampler.every, sampler.interval, sampler.level = defaultSampleEvery, defaultSampleInterval, defaultSampleLevel
+	if options.First != nil {
+		sampler.first = max(*options.First, 0)
+	}
+	if options.Every != nil {
+		sampler.every = max(*options.Every, 0)
+	}
+	if options.Interval != nil && *options.Interval > 0 {
+		sampler.interval = *options.Interval
+	}
+	if options.Level != nil {
+		sampler.level = *options.Level
+	}
+	sampler.levels = map[Level]SampleRule{}
+	for level, rule := range options.Levels {
+		sampler.levels[level] = SampleRule{First: max(rule.First, 0), Every: max(rule.Every, 0)}
+	}
+	return builder
+}
+
+func (builder *samplerBuilder) setRateLimit() *samplerBuilder {
+	options, sampler := builder.options, builder.sampler
+	if options.RateLimit != nil {
+		sampler.rateLimit = float64(max(*options.RateLimit, 0))
+	}
+	sampler.rateBurst = sampler.rateLimit
+	if options.RateBurst != nil && *options.RateBurst > 0 {
+		sampler.rateBurst = float64(*options.RateBurst)
+	}
+	return builder
+}
+
+func (builder *samplerBuilder) setDedup() *samplerBuilder {
+	if interval := builder.options.DedupInterval; interval != nil {
+		builder.sampler.dedupInterval = max(*interval, 0)
+	}
+	return builder
+}
@@ -0,0 +1,232 @@
+package xbmetric
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	KindCounter   = "counter"
+	KindGauge     = "gauge"
+	KindHistogram = "histogram"
+)
+
+var (
+	DurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	SizeBuckets     = []float64{1 << 6, 1 << 8, 1 << 10, 1 << 12, 1 << 14, 1 << 16, 1 << 18, 1 << 20, 1 << 22}
+)
+
+var (
+	nameRegexp  = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// mRegistry is created eagerly, since the metrics get registered from any
+// goroutine, e.g. the first flow of a daemon.
+var mRegistry = NewRegistry()
+
+func GetRegistry() *Registry {
+	return mRegistry
+}
+
+func NewRegistry() *Registry {
+	registry := &Registry{metricMap: map[string]*metric{}}
+	return registry
+}
+
+type Registry struct {
+	mutex     sync.RWMutex
+	metricMap map[string]*metric
+}
+
+func (registry *Registry) register(metric *metric) *metric {
+	if !nameRegexp.MatchString(metric.name) {
+		panic(fmt.Sprintf("Metric name `%s` is invalid.", metric.name))
+	}
+	for _, label := range metric.labels {
+		if !labelRegexp.MatchString(label) || strings.HasPrefix(label, "__") {
+			panic(fmt.Sprintf("Metric `%s` has invalid label `%s`.", metric.name, label))
+		}
+	}
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if _, ok := registry.metricMap[metric.name]; ok {
+		panic(fmt.Sprintf("Duplicate metric `%s` is found.", metric.name))
+	}
+	registry.metricMap[metric.name] = metric
+	return metric
+}
+
+func (registry *Registry) getMetrics() []*metric {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	metrics := make([]*metric, 0, len(registry.metricMap))
+	for _, metric := range registry.metricMap {
+		metrics = append(metrics, metric)
+	}
+	slices.SortFunc(metrics, func(a, b *metric) int {
+		return strings.Compare(a.name, b.name)
+	})
+	return metrics
+}
+
+func (registry *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{metric: registry.register(newMetric(KindCounter, name, help, nil, labels))}
+}
+
+func (registry *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{metric: registry.register(newMetric(KindGauge, name, help, nil, labels))}
+}
+
+func (registry *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if len(buckets) == 0 || !slices.IsSorted(buckets) {
+		panic(fmt.Sprintf("Histogram `%s` must have sorted buckets.", name))
+	}
+	return &Histogram{metric: registry.register(newMetric(KindHistogram, name, help, buckets, labels))}
+}
+
+// NewCounter, NewGauge and NewHistogram register the metric into the default
+// registry, e.g. `xbmetric.NewCounter("job_total", "Jobs done.", "status")`.
+func NewCounter(name, help string, labels ...string) *Counter {
+	return GetRegistry().NewCounter(name, help, labels...)
+}
+
+func NewGauge(name, help string, labels ...string) *Gauge {
+	return GetRegistry().NewGauge(name, help, labels...)
+}
+
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return GetRegistry().NewHistogram(name, help, buckets, labels...)
+}
+
+type Counter struct {
+	metric *metric
+}
+
+func (counter *Counter) Inc(values ...string) {
+	counter.Add(1, values...)
+}
+
+func (counter *Counter) Add(delta float64, values ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("Counter `%s` can't be decreased.", counter.metric.name))
+	}
+	counter.metric.update(values, func(series *series) {
+		series.value += delta
+	})
+}
+
+type Gauge struct {
+	metric *metric
+}
+
+func (gauge *Gauge) Set(value float64, values ...string) {
+	gauge.metric.update(values, func(series *series) {
+		series.value = value
+	})
+}
+
+func (gauge *Gauge) Add(delta float64, values ...string) {
+	gauge.metric.update(values, func(series *series) {
+		series.value += delta
+	})
+}
+
+func (gauge *Gauge) Inc(values ...string) {
+	gauge.Add(1, values...)
+}
+
+func (gauge *Gauge) Dec(values ...string) {
+	gauge.Add(-1, values...)
+}
+
+type Histogram struct {
+	metric *metric
+}
+
+func (histogram *Histogram) Observe(value float64, values ...string) {
+	histogram.metric.update(values, func(series *series) {
+		if index, _ := slices.BinarySearch(histogram.metric.buckets, value); index < len(series.counts) {
+			series.counts[index]++
+		}
+		series.count++
+		series.sum += value
+	})
+}
+
+type metric struct {
+	kind      string
+	name      string
+	help      string
+	labels    []string
+	buckets   []float64
+	mutex     sync.Mutex
+	seriesMap map[string]*series
+}
+
+type series struct {
+	values []string
+	value  float64
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newMetric(kind, name, help string, buckets []float64, labels []string) *metric {
+	metric := &metric{
+		kind:      kind,
+		name:      name,
+		help:      help,
+		labels:    slices.Clone(labels),
+		buckets:   slices.Clone(buckets),
+		seriesMap: map[string]*series{},
+	}
+	return metric
+}
+
+func (metric *metric) update(values []string, operate func(series *series)) {
+	if len(values) != len(metric.labels) {
+		panic(fmt.Sprintf("Metric `%s` requires %d label values but got `%v`.", metric.name, len(metric.labels), values))
+	}
+	key := strings.Join(values, "\xff")
+	metric.mutex.Lock()
+	defer metric.mutex.Unlock()
+	current, ok := metric.seriesMap[key]
+	if !ok {
+		current = &series{values: slices.Clone(values), counts: make([]uint64, len(metric.buckets))}
+		metric.seriesMap[key] = current
+	}
+	operate(current)
+}
+
+func (metric *metric) snapshot() []*series {
+	metric.mutex.Lock()
+	defer metric.mutex.Unlock()
+	snapshot := make([]*series, 0, len(metric.seriesMap))
+	for _, current := range metric.seriesMap {
+		copied := *current
+		copied.counts = slices.Clone(current.counts)
+		snapshot = append(snapshot, &copied)
+	}
+	slices.SortFunc(snapshot, func(a, b *series) int {
+		return slices.Compare(a.values, b.values)
+	})
+	return snapshot
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,62 @@
+package xbmetric
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+)
+
+const TextContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// WriteText writes every metric of the registry in the Prometheus text
+// exposition format.
+func (registry *Registry) WriteText(writer io.Writer) error {
+	buffer := bufio.NewWriter(writer)
+	for _, metric := range registry.getMetrics() {
+		fmt.Fprintf(buffer, "# HELP %s %s\n", metric.name, helpReplacer.Replace(metric.help))
+		fmt.Fprintf(buffer, "# TYPE %s %s\n", metric.name, metric.kind)
+		for _, series := range metric.snapshot() {
+			if metric.kind != KindHistogram {
+				writeSample(buffer, metric.name, metric.labels, series.values, "", series.value)
+				continue
+			}
+			labels := append(slices.Clip(metric.labels), "le")
+			values := slices.Clip(series.values)
+			count := uint64(0)
+			for i, bucket := range metric.buckets {
+				count += series.counts[i]
+				writeSample(buffer, metric.name+"_bucket", labels,
+					append(values, formatFloat(bucket)), "", float64(count))
+			}
+			writeSample(buffer, metric.name+"_bucket", labels,
+				append(values, formatFloat(math.Inf(1))), "", float64(series.count))
+			writeSample(buffer, metric.name, metric.labels, series.values, "_sum", series.sum)
+			writeSample(buffer, metric.name, metric.labels, series.values, "_count", float64(series.count))
+		}
+	}
+	return buffer.Flush()
+}
+
+func WriteText(writer io.Writer) error {
+	return GetRegistry().WriteText(writer)
+}
+
+func writeSample(writer io.Writer, name string, labels, values []string, suffix string, value float64) {
+	pairs := make([]string, len(labels))
+	for i, label := range labels {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, label, labelReplacer.Replace(values[i]))
+	}
+	if len(pairs) == 0 {
+		fmt.Fprintf(writer, "%s%s %s\n", name, suffix, formatFloat(value))
+	} else {
+		fmt.Fprintf(writer, "%s%s{%s} %s\n", name, suffix, strings.Join(pairs, ","), formatFloat(value))
+	}
+}
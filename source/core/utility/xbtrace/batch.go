@@ -0,0 +1,185 @@
+package xbtrace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultBatchQueueSize = 2048
+	defaultBatchSize      = 512
+	defaultBatchInterval  = 5 * time.Second
+	defaultBatchTimeout   = 30 * time.Second
+)
+
+type BatchExporterOptions struct {
+	QueueSize *int
+	BatchSize *int
+	Interval  *time.Duration
+	Timeout   *time.Duration
+}
+
+// BatchExporter takes the spans off the ending goroutine through a bounded
+// queue, which drops them once full, and hands them to the exporter in batches
+// of the batch size or every interval, whichever comes first.
+type BatchExporter struct {
+	mutex     sync.RWMutex
+	exporter  Exporter
+	queue     chan *SpanData
+	flushes   chan chan struct{}
+	done      chan struct{}
+	closed    bool
+	batchSize int
+	interval  time.Duration
+	timeout   time.Duration
+	dropped   atomic.Uint64
+}
+
+func NewBatchExporter(exporter Exporter, options *BatchExporterOptions) *BatchExporter {
+	if options == nil {
+		options = &BatchExporterOptions{}
+	}
+	batch := &BatchExporter{
+		exporter:  exporter,
+		queue:     make(chan *SpanData, defaultBatchQueueSize),
+		flushes:   make(chan chan struct{}),
+		done:      make(chan struct{}),
+		batchSize: defaultBatchSize,
+		interval:  defaultBatchInterval,
+		timeout:   defaultBatchTimeout,
+	}
+	if options.QueueSize != nil && *options.QueueSize > 0 {
+		batch.queue = make(chan *SpanData, *options.QueueSize)
+	}
+	if options.BatchSize != nil && *options.BatchSize > 0 {
+		batch.batchSize = *options.BatchSize
+	}
+	if options.Interval != nil && *options.Interval > 0 {
+		batch.interval = *options.Interval
+	}
+	if options.Timeout != nil && *options.Timeout > 0 {
+		batch.timeout = *options.Timeout
+	}
+	go batch.run()
+	return batch
+}
+
+func (batch *BatchExporter) ExportSpans(ctx context.Context, spans []*SpanData) error {
+	batch.mutex.RLock()
+	defer batch.mutex.RUnlock()
+	if batch.closed {
+		return fmt.Errorf("Batch span exporter has been shut down.")
+	}
+	for _, span := range spans {
+		select {
+		case batch.queue <- span:
+		default:
+			batch.dropped.Add(1)
+		}
+	}
+	return nil
+}
+
+func (batch *BatchExporter) GetDropped() uint64 {
+	return batch.dropped.Load()
+}
+
+// Flush waits until the spans queued so far have been exported.
+func (batch *BatchExporter) Flush(ctx context.Context) error {
+	batch.mutex.RLock()
+	if batch.closed {
+		batch.mutex.RUnlock()
+		return nil
+	}
+	flushed := make(chan struct{})
+	select {
+	case batch.flushes <- flushed:
+	case <-ctx.Done():
+		batch.mutex.RUnlock()
+		return fmt.Errorf("Batch span exporter failed to flush: %w", ctx.Err())
+	}
+	batch.mutex.RUnlock()
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("Batch span exporter failed to flush: %w", ctx.Err())
+	}
+}
+
+// Shutdown exports the pending spans before shutting the exporter down.
+func (batch *BatchExporter) Shutdown(ctx context.Context) error {
+	batch.mutex.Lock()
+	if batch.closed {
+		batch.mutex.Unlock()
+		return nil
+	}
+	batch.closed = true
+	close(batch.queue)
+	batch.mutex.Unlock()
+	select {
+	case <-batch.done:
+	case <-ctx.Done():
+		return fmt.Errorf("Batch span exporter failed to shut down: %w", ctx.Err())
+	}
+	return batch.exporter.Shutdown(ctx)
+}
+
+func (batch *BatchExporter) run() {
+	defer close(batch.done)
+	ticker := time.NewTicker(batch.interval)
+	defer ticker.Stop()
+	spans := make([]*SpanData, 0, batch.batchSize)
+	for {
+		select {
+		case span, ok := <-batch.queue:
+			if !ok {
+				batch.export(spans)
+				return
+			}
+			spans = append(spans, span)
+			if len(spans) >= batch.batchSize {
+				spans = batch.export(spans)
+			}
+		case <-ticker.C:
+			spans = batch.export(spans)
+		case flushed := <-batch.flushes:
+			for len(batch.queue) > 0 {
+				spans = append(spans, <-batch.queue)
+			}
+			spans = batch.export(spans)
+			close(flushed)
+		}
+	}
+}
+
+func (batch *BatchExporter) export(spans []*SpanData) []*SpanData {
+	if len(spans) == 0 {
+		return spans
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), batch.timeout)
+	defer cancel()
+	if err := batch.exporter.ExportSpans(ctx, spans); err != nil {
+		fmt.Fprintf(os.Stderr, "Tracer failed to export %d spans: %v\n", len(spans), err)
+	}
+	return make([]*SpanData, 0, batch.batchSize)
+}
+
+// Flush waits until the spans ended so far have been exported, e.g. before
+// the process exits.
+func Flush(ctx context.Context) error {
+	if batch, ok := GetExporter().(*BatchExporter); ok {
+		return batch.Flush(ctx)
+	}
+	return nil
+}
+
+func FlushWithTimeout(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return Flush(ctx)
+}
@@ -0,0 +1,127 @@
+package xbtrace
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/toolkit/xbrand"
+)
+
+const (
+	HeaderTraceparent = "Traceparent"
+	HeaderTracestate  = "Tracestate"
+)
+
+const (
+	traceparentVersion = "00"
+	flagSampled        = 0x01
+)
+
+var (
+	traceparentRegexp = regexp.MustCompile(`^([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})(-.*)?$`)
+	zeroTraceID       = strings.Repeat("0", 32)
+	zeroSpanID        = strings.Repeat("0", 16)
+)
+
+// SpanContext is the part of a span propagated across process boundaries, as
+// defined by the W3C Trace Context specification.
+type SpanContext struct {
+	traceID    string
+	spanID     string
+	flags      byte
+	traceState string
+}
+
+func NewSpanContext() *SpanContext {
+	return &SpanContext{traceID: MakeTraceID(), spanID: MakeSpanID(), flags: flagSampled}
+}
+
+// ParseTraceparent reads the `traceparent` and `tracestate` header values and
+// reports false when the trace context is absent or malformed.
+func ParseTraceparent(traceparent, tracestate string) (*SpanContext, bool) {
+	matches := traceparentRegexp.FindStringSubmatch(strings.TrimSpace(traceparent))
+	if matches == nil {
+		return nil, false
+	}
+	version, traceID, spanID, flags, rest := matches[1], matches[2], matches[3], matches[4], matches[5]
+	if version == "ff" || (version == traceparentVersion && rest != "") {
+		return nil, false
+	}
+	if traceID == zeroTraceID || spanID == zeroSpanID {
+		return nil, false
+	}
+	bytes, _ := hex.DecodeString(flags)
+	return &SpanContext{traceID: traceID, spanID: spanID, flags: bytes[0], traceState: strings.TrimSpace(tracestate)}, true
+}
+
+// Extract reads the trace context of an inbound request.
+func Extract(header http.Header) (*SpanContext, bool) {
+	return ParseTraceparent(header.Get(HeaderTraceparent), header.Get(HeaderTracestate))
+}
+
+// Inject writes the trace context into an outbound request.
+func Inject(header http.Header, spanContext *SpanContext) {
+	if spanContext == nil {
+		return
+	}
+	header.Set(HeaderTraceparent, spanContext.Traceparent())
+	if spanContext.traceState != "" {
+		header.Set(HeaderTracestate, spanContext.traceState)
+	}
+}
+
+// Child keeps the trace ID, flags and state but gets a new span ID.
+func (spanContext *SpanContext) Child() *SpanContext {
+	return &SpanContext{
+		traceID:    spanContext.traceID,
+		spanID:     MakeSpanID(),
+		flags:      spanContext.flags,
+		traceState: spanContext.traceState,
+	}
+}
+
+func (spanContext *SpanContext) GetTraceID() string {
+	return spanContext.traceID
+}
+
+func (spanContext *SpanContext) GetSpanID() string {
+	return spanContext.spanID
+}
+
+func (spanContext *SpanContext) GetTraceState() string {
+	return spanContext.traceState
+}
+
+func (spanContext *SpanContext) IsSampled() bool {
+	return spanContext.flags&flagSampled != 0
+}
+
+func (spanContext *SpanContext) Traceparent() string {
+	return fmt.Sprintf("%s-%s-%s-%02x", traceparentVersion, spanContext.traceID, spanContext.spanID, spanContext.flags)
+}
+
+func MakeTraceID() string {
+	return hex.EncodeToString(xbrand.MakeBytes(16))
+}
+
+func MakeSpanID() string {
+	return hex.EncodeToString(xbrand.MakeBytes(8))
+}
+
+type contextKey struct{}
+
+func IntoContext(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, contextKey{}, span)
+}
+
+func FromContext(ctx context.Context) (*Span, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	span, ok := ctx.Value(contextKey{}).(*Span)
+	return span, ok
+}
@@ -0,0 +1,194 @@
+package xbtrace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Span kinds and status codes follow the OTLP numbering.
+const (
+	SpanKindInternal = 1
+	SpanKindServer   = 2
+	SpanKindClient   = 3
+)
+
+const (
+	StatusCodeUnset = 0
+	StatusCodeOK    = 1
+	StatusCodeError = 2
+)
+
+// Exporter mirrors the OTLP span exporter, so that an OTLP client can be
+// plugged in by a thin adapter.
+type Exporter interface {
+	ExportSpans(ctx context.Context, spans []*SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+var mExporter atomic.Pointer[exporterHolder]
+
+type exporterHolder struct {
+	exporter Exporter
+}
+
+// SetExporter exports the spans in batches, see `BatchExporter`, so that the
+// ending goroutines never wait for the exporter. The spans which have ended
+// can be exported at once by `Flush`. The replaced batch exporter is shut down
+// after exporting its pending spans.
+func SetExporter(exporter Exporter) {
+	if _, ok := exporter.(*BatchExporter); !ok && exporter != nil {
+		exporter = NewBatchExporter(exporter, nil)
+	}
+	prev := mExporter.Swap(&exporterHolder{exporter: exporter})
+	if prev == nil {
+		return
+	}
+	if batch, ok := prev.exporter.(*BatchExporter); ok && batch != exporter {
+		ctx, cancel := context.WithTimeout(context.Background(), batch.timeout)
+		defer cancel()
+		if err := batch.Shutdown(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "Tracer failed to shut down the replaced exporter: %v\n", err)
+		}
+	}
+}
+
+func GetExporter() Exporter {
+	if holder := mExporter.Load(); holder != nil {
+		return holder.exporter
+	}
+	return nil
+}
+
+// StartSpan starts a child of the given parent, or a new trace when the
+// parent is nil.
+func StartSpan(name string, kind int, parent *SpanContext) *Span {
+	span := &Span{data: &SpanData{Name: name, Kind: kind, StartTime: time.Now(), Attributes: map[string]any{}}}
+	if parent == nil {
+		span.context = NewSpanContext()
+	} else {
+		span.context = parent.Child()
+		span.data.ParentSpanID = parent.spanID
+	}
+	span.data.TraceID = span.context.traceID
+	span.data.SpanID = span.context.spanID
+	span.data.TraceState = span.context.traceState
+	return span
+}
+
+type Span struct {
+	mutex   sync.Mutex
+	context *SpanContext
+	data    *SpanData
+	isEnded bool
+}
+
+type SpanData struct {
+	TraceID       string         `json:"traceId"`
+	SpanID        string         `json:"spanId"`
+	ParentSpanID  string         `json:"parentSpanId,omitempty"`
+	TraceState    string         `json:"traceState,omitempty"`
+	Name          string         `json:"name"`
+	Kind          int            `json:"kind"`
+	StartTime     time.Time      `json:"startTime"`
+	EndTime       time.Time      `json:"endTime"`
+	Attributes    map[string]any `json:"attributes,omitempty"`
+	StatusCode    int            `json:"statusCode"`
+	StatusMessage string         `json:"statusMessage,omitempty"`
+}
+
+func (data *SpanData) GetDuration() time.Duration {
+	return data.EndTime.Sub(data.StartTime)
+}
+
+func (span *Span) GetContext() *SpanContext {
+	return span.context
+}
+
+func (span *Span) SetName(name string) {
+	span.mutex.Lock()
+	defer span.mutex.Unlock()
+	span.data.Name = name
+}
+
+func (span *Span) SetAttribute(key string, value any) {
+	span.mutex.Lock()
+	defer span.mutex.Unlock()
+	span.data.Attributes[key] = value
+}
+
+func (span *Span) SetError(err error) {
+	if err == nil {
+		return
+	}
+	span.mutex.Lock()
+	defer span.mutex.Unlock()
+	span.data.StatusCode = StatusCodeError
+	span.data.StatusMessage = err.Error()
+}
+
+func (span *Span) SetOK() {
+	span.mutex.Lock()
+	defer span.mutex.Unlock()
+	span.data.StatusCode = StatusCodeOK
+}
+
+// End stamps the span and hands it to the exporter once; spans of unsampled
+// traces are dropped.
+func (span *Span) End() {
+	span.mutex.Lock()
+	if span.isEnded {
+		span.mutex.Unlock()
+		return
+	}
+	span.isEnded = true
+	span.data.EndTime = time.Now()
+	data := *span.data
+	data.Attributes = make(map[string]any, len(span.data.Attributes))
+	for key, value := range span.data.Attributes {
+		data.Attributes[key] = value
+	}
+	span.mutex.Unlock()
+	if exporter := GetExporter(); exporter != nil && span.context.IsSampled() {
+		if err := exporter.ExportSpans(context.Background(), []*SpanData{&data}); err != nil {
+			fmt.Fprintf(os.Stderr, "Tracer failed to export span: %v\n", err)
+		}
+	}
+}
+
+func NewMemoryExporter() *MemoryExporter {
+	return &MemoryExporter{}
+}
+
+// MemoryExporter keeps the exported spans in memory and is meant for tests.
+type MemoryExporter struct {
+	mutex sync.Mutex
+	spans []*SpanData
+}
+
+func (exporter *MemoryExporter) ExportSpans(ctx context.Context, spans []*SpanData) error {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+	exporter.spans = append(exporter.spans, spans...)
+	return nil
+}
+
+func (exporter *MemoryExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func (exporter *MemoryExporter) GetSpans() []*SpanData {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+	return slices.Clone(exporter.spans)
+}
+
+func (exporter *MemoryExporter) Reset() {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+	exporter.spans = nil
+}
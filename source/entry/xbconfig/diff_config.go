@@ -0,0 +1,34 @@
+package xbconfig
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/toolkit/xbvalue"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xberror"
+	"github.com/starryck/strk-tc-x-lib-go/source/entry/xbprecfg"
+)
+
+// ExecuteDiff compares the effective config with the one an environment file
+// alone would produce, i.e. without the process environment and flags.
+func ExecuteDiff(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return xberror.Wrapf("Config diff failed to find file `%s`.", []any{path}, err)
+	}
+	other := xbprecfg.LoadConfig(&xbprecfg.ConfigOptions{
+		FilePath:    xbvalue.Refer(""),
+		DotenvPaths: []string{path},
+		Environment: map[string]string{},
+	})
+	changes := xbprecfg.DiffConfigs(xbcfg.GetConfig(), other)
+	if len(changes) == 0 {
+		fmt.Printf("Config is identical to `%s`.\n", path)
+		return nil
+	}
+	fmt.Printf("Config differs from `%s` in %d item(s):\n", path, len(changes))
+	for _, change := range changes {
+		fmt.Printf("  %s (%s): %q -> %q\n", change.Key, change.Path, change.Prev, change.Next)
+	}
+	return nil
+}
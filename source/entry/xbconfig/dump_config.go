@@ -0,0 +1,84 @@
+package xbconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xberror"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbjson"
+	"github.com/starryck/strk-tc-x-lib-go/source/entry/xbprecfg"
+)
+
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatEnv  = "env"
+)
+
+func ExecuteDump(format string) error {
+	var text string
+	var err error
+	config := xbcfg.GetConfig()
+	switch format {
+	case FormatJSON:
+		text, err = makeJSONText(config)
+	case FormatYAML:
+		text, err = makeYAMLText(config)
+	case FormatEnv:
+		text = makeEnvText(xbprecfg.ListConfigItems(config))
+	default:
+		return xberror.Newf("Config dump doesn't support format `%s`.", []any{format})
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(text)
+	return nil
+}
+
+func makeJSONText(config xbcfg.Config) (string, error) {
+	data, err := xbjson.MarshalIndent(config, "", "  ")
+	return string(data), err
+}
+
+// The config goes through JSON first, so that the `json` tags and the secret
+// redaction apply to YAML as well.
+func makeYAMLText(config xbcfg.Config) (string, error) {
+	data, err := xbjson.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	node := &yaml.Node{}
+	if err := yaml.Unmarshal(data, node); err != nil {
+		return "", err
+	}
+	resetYAMLStyle(node)
+	data, err = yaml.Marshal(node)
+	return strings.TrimRight(string(data), "\n"), err
+}
+
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, subnode := range node.Content {
+		resetYAMLStyle(subnode)
+	}
+}
+
+func makeEnvText(items []*xbprecfg.ConfigItem) string {
+	lines := make([]string, len(items))
+	for i, item := range items {
+		lines[i] = fmt.Sprintf("%s=%s", item.Key, makeEnvValue(item.Value))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func makeEnvValue(value string) string {
+	if strings.ContainsAny(value, " \t\n\"'#=$\\") {
+		return strconv.Quote(value)
+	}
+	return value
+}
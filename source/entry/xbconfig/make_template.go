@@ -0,0 +1,60 @@
+package xbconfig
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
+	"github.com/starryck/strk-tc-x-lib-go/source/entry/xbprecfg"
+)
+
+// ExecuteTemplate generates a commented .env template from the `env`,
+// `envDefault` and `validate` tags of the config, which is printed when no
+// output path is given.
+func ExecuteTemplate(path string) error {
+	config := xbcfg.GetConfig()
+	text := makeTemplateText(fmt.Sprintf("%T", config), xbprecfg.ListConfigItems(config))
+	if path == "" {
+		fmt.Print(text)
+		return nil
+	}
+	return os.WriteFile(path, []byte(text), 0644)
+}
+
+func makeTemplateText(typeName string, items []*xbprecfg.ConfigItem) string {
+	builder := &strings.Builder{}
+	fmt.Fprintf(builder, "# Generated from the tags of `%s`.\n", typeName)
+	section := ""
+	for _, item := range items {
+		if mSection := makeTemplateSection(item.Path); mSection != section {
+			section = mSection
+			fmt.Fprintf(builder, "\n# [%s]\n", section)
+		}
+		fmt.Fprintf(builder, "\n# %s\n", makeTemplateComment(item))
+		if item.IsSecret {
+			fmt.Fprintf(builder, "# Secret, which may also be read from the file given by %s%s.\n",
+				item.Key, xbprecfg.SecretFileSuffix)
+		}
+		fmt.Fprintf(builder, "%s=%s\n", item.Key, makeEnvValue(item.Fallback))
+	}
+	return builder.String()
+}
+
+func makeTemplateSection(path string) string {
+	if index := strings.LastIndex(path, "."); index >= 0 {
+		return path[:index]
+	}
+	return "base"
+}
+
+func makeTemplateComment(item *xbprecfg.ConfigItem) string {
+	parts := []string{fmt.Sprintf("%s (%s)", item.Path, item.Type)}
+	if item.Fallback != "" {
+		parts = append(parts, fmt.Sprintf("default: %s", item.Fallback))
+	}
+	if item.Rules != "" {
+		parts = append(parts, fmt.Sprintf("rules: %s", item.Rules))
+	}
+	return strings.Join(parts, ", ")
+}
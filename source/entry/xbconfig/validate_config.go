@@ -0,0 +1,16 @@
+package xbconfig
+
+import (
+	"fmt"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
+	"github.com/starryck/strk-tc-x-lib-go/source/entry/xbprecfg"
+)
+
+func ExecuteValidate() error {
+	if err := xbprecfg.ValidateConfig(xbcfg.GetConfig()); err != nil {
+		return err
+	}
+	fmt.Println("Config is valid.")
+	return nil
+}
@@ -3,11 +3,17 @@ package xbinfo
 import (
 	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
 	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xblogger"
+	"github.com/starryck/strk-tc-x-lib-go/source/entry/xbprecfg"
 )
 
 func Execute() error {
-	xblogger.WithFields(xblogger.Fields{
-		"config": xbcfg.GetConfig(),
-	}).Info("Log info message.")
+	config := xbcfg.GetConfig()
+	fields := xblogger.Fields{
+		"config": config,
+	}
+	if config, ok := config.(xbprecfg.SourceConfig); ok {
+		fields["sources"] = config.GetSources()
+	}
+	xblogger.WithFields(fields).Info("Log info message.")
 	return nil
 }
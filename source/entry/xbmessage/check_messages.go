@@ -0,0 +1,21 @@
+package xbmessage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbmtmsg"
+)
+
+func ExecuteCheck() error {
+	errs := xbmtmsg.CheckMetaMessages()
+	if len(errs) == 0 {
+		fmt.Printf("Messages are consistent in %d code(s).\n", len(xbmtmsg.ListMetaMessages()))
+		return nil
+	}
+	lines := make([]string, len(errs))
+	for i, err := range errs {
+		lines[i] = fmt.Sprintf("  %s", err)
+	}
+	return fmt.Errorf("Messages check found %d issue(s):\n%s", len(errs), strings.Join(lines, "\n"))
+}
@@ -0,0 +1,115 @@
+package xbmessage
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbmtmsg"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xberror"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbjson"
+)
+
+const (
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+	FormatOpenAPI  = "openapi"
+)
+
+const openAPIResponseSchema = "MetaMessageResponse"
+
+func ExecuteExport(format string) error {
+	var text string
+	var err error
+	entries := xbmtmsg.ListMetaMessageEntries()
+	switch format {
+	case FormatJSON:
+		text, err = makeJSONText(entries)
+	case FormatMarkdown:
+		text = makeMarkdownText(entries)
+	case FormatOpenAPI:
+		text, err = makeOpenAPIText(entries)
+	default:
+		return xberror.Newf("Messages export doesn't support format `%s`.", []any{format})
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(text)
+	return nil
+}
+
+func makeJSONText(entries []*xbmtmsg.MetaMessageEntry) (string, error) {
+	data, err := xbjson.MarshalIndent(entries, "", "  ")
+	return string(data), err
+}
+
+func makeMarkdownText(entries []*xbmtmsg.MetaMessageEntry) string {
+	lines := []string{
+		"| Code | Out code | HTTP code | Out text | Log text | Package |",
+		"| --- | --- | --- | --- | --- | --- |",
+	}
+	for _, entry := range entries {
+		lines = append(lines, fmt.Sprintf("| %s | %s | %d | %s | %s | %s |",
+			entry.Code, entry.OutCode, entry.HTTPCode, escapeMarkdownCell(entry.OutText),
+			escapeMarkdownCell(entry.LogText), escapeMarkdownCell(entry.Package)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func escapeMarkdownCell(text string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(text)
+}
+
+// makeOpenAPIText makes the `components` object of an OpenAPI document, with a
+// response for each error message, i.e. of HTTP code 4xx or 5xx, to be
+// referenced as `#/components/responses/{code}`. The map keys are sorted, so
+// that the output is stable across runs.
+func makeOpenAPIText(entries []*xbmtmsg.MetaMessageEntry) (string, error) {
+	responses := map[string]any{}
+	for _, entry := range entries {
+		if entry.HTTPCode < http.StatusBadRequest {
+			continue
+		}
+		description := entry.OutText
+		if statusText := http.StatusText(entry.HTTPCode); statusText != "" {
+			description = fmt.Sprintf("%s %s", statusText, entry.OutText)
+		}
+		responses[entry.Code] = map[string]any{
+			"description": description,
+			"content": map[string]any{
+				"application/json": map[string]any{
+					"schema": map[string]any{"$ref": "#/components/schemas/" + openAPIResponseSchema},
+					"example": map[string]any{
+						"meta": map[string]any{"code": entry.OutCode, "message": entry.OutText},
+						"data": nil,
+					},
+				},
+			},
+		}
+	}
+	components := map[string]any{
+		"components": map[string]any{
+			"schemas": map[string]any{
+				openAPIResponseSchema: map[string]any{
+					"type":     "object",
+					"required": []string{"meta", "data"},
+					"properties": map[string]any{
+						"meta": map[string]any{
+							"type":     "object",
+							"required": []string{"code", "message"},
+							"properties": map[string]any{
+								"code":    map[string]any{"type": "string"},
+								"message": map[string]any{"type": "string"},
+							},
+						},
+						"data": map[string]any{"nullable": true},
+					},
+				},
+			},
+			"responses": responses,
+		},
+	}
+	data, err := xbjson.MarshalIndentSorted(components, "", "  ")
+	return string(data), err
+}
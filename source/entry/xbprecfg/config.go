@@ -2,24 +2,102 @@ package xbprecfg
 
 import (
 	"fmt"
+	"os"
 	"path"
+	"reflect"
 	"runtime"
+	"strings"
 
 	"github.com/caarlos0/env/v11"
 
 	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
 	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbconst"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbtype"
 	"github.com/starryck/strk-tc-x-lib-go/source/core/toolkit/xbrand"
 )
 
 func NewConfig() *Config {
-	config := (&configBuilder{}).initialize().
+	return NewConfigWithOptions(nil)
+}
+
+func NewConfigWithOptions(options *ConfigOptions) *Config {
+	config := &Config{}
+	buildConfig(config, options)
+	return config
+}
+
+type ExtendedConfig[T any] interface {
+	*T
+	xbcfg.Config
+}
+
+// NewExtendedConfig builds a service config which embeds `Config` and adds its
+// own sections as struct fields, e.g.
+//
+//	type ServiceConfig struct {
+//		xbprecfg.Config
+//		Redis RedisConfig `json:"redis" envPrefix:"REDIS_"`
+//	}
+//
+// The sections can then be retrieved by `xbcfg.Section[RedisConfig]()`.
+func NewExtendedConfig[T any, P ExtendedConfig[T]](options *ConfigOptions) P {
+	config := P(new(T))
+	buildConfig(config, options)
+	return config
+}
+
+func buildConfig(config xbcfg.Config, options *ConfigOptions) {
+	(&configBuilder{target: config, options: options}).
+		initialize().
+		loadFile().
+		loadDotenv().
+		loadEnv().
+		loadFlags().
+		loadSecretFiles().
 		parseEnv().
 		setBasePath().
 		setServiceID().
 		setServiceDeveloping().
+		setSections().
 		build()
-	return config
+}
+
+type ConfigFactory = func(options *ConfigOptions) xbcfg.Config
+
+var mConfigFactory ConfigFactory = func(options *ConfigOptions) xbcfg.Config {
+	return NewConfigWithOptions(options)
+}
+
+// mConfigTemplate only describes the config fields, e.g. to make the flags.
+var mConfigTemplate xbcfg.Config = &Config{}
+
+// UseExtendedConfig makes `LoadConfig`, `SetupConfig`, `ApplyFlags` and
+// `ReloadConfig` build the given extended config instead of `Config`. It must
+// be called before the config is first used, e.g. in the `init` of `main`.
+func UseExtendedConfig[T any, P ExtendedConfig[T]]() {
+	if _, ok := xbcfg.LookupConfig(); ok {
+		panic("Config has been loaded before `UseExtendedConfig` is called.")
+	}
+	mConfigFactory = func(options *ConfigOptions) xbcfg.Config {
+		return NewExtendedConfig[T, P](options)
+	}
+	mConfigTemplate = P(new(T))
+}
+
+func LoadConfig(options *ConfigOptions) xbcfg.Config {
+	return mConfigFactory(options)
+}
+
+// SetupConfig loads and sets the config explicitly, and returns its problems.
+// An invalid config is never set.
+func SetupConfig(options *ConfigOptions) error {
+	config := LoadConfig(options)
+	if err := ValidateConfig(config); err != nil {
+		return err
+	}
+	mConfigOptions = options
+	xbcfg.SetConfig(config)
+	return nil
 }
 
 var ServiceEnvironmentDevelopingMap = map[string]bool{
@@ -63,22 +141,35 @@ type Config struct {
 	GitCommit string `json:"gitCommit" env:"GIT_COMMIT"`
 
 	ServiceID          string `json:"serviceID" env:"-"`
-	ServiceCode        string `json:"serviceCode" env:"SRV_CODE" envDefault:"S001"`
-	ServiceName        string `json:"serviceName" env:"SRV_NAME" envDefault:"lib-go"`
-	ServicePort        int    `json:"servicePort" env:"SRV_PORT" envDefault:"80"`
+	ServiceCode        string `json:"serviceCode" env:"SRV_CODE" envDefault:"S001" validate:"required"`
+	ServiceName        string `json:"serviceName" env:"SRV_NAME" envDefault:"lib-go" validate:"required"`
+	ServicePort        int    `json:"servicePort" env:"SRV_PORT" envDefault:"80" validate:"range:1,65535"`
 	ServiceProject     string `json:"serviceProject" env:"SRV_PROJECT" envDefault:"x"`
 	ServiceVersion     string `json:"serviceVersion" env:"SRV_VERSION" envDefault:"v1"`
-	ServiceEnvironment string `json:"serviceEnvironment" env:"SRV_ENVIRONMENT" envDefault:"prod"`
-	ServiceLogLevel    string `json:"serviceLogLevel" env:"SRV_LOG_LEVEL" envDefault:"info"`
+	ServiceEnvironment string `json:"serviceEnvironment" env:"SRV_ENVIRONMENT" envDefault:"prod" validate:"environment"`
+	ServiceLogLevel    string `json:"serviceLogLevel" env:"SRV_LOG_LEVEL" envDefault:"info" validate:"logLevel"`
+	ServiceErrorStack  bool   `json:"serviceErrorStack" env:"SRV_ERROR_STACK" envDefault:"false"`
 	ServiceTesting     bool   `json:"serviceTesting" env:"SRV_TESTING" envDefault:"false"`
 	ServiceDebugging   bool   `json:"serviceDebugging" env:"SRV_DEBUGGING" envDefault:"false"`
 	ServiceDeveloping  bool   `json:"serviceDeveloping" env:"-"`
 
-	PostgresHost     string `json:"postgresHost" env:"POSTGRES_HOST"`
-	PostgresPort     string `json:"postgresPort" env:"POSTGRES_PORT" envDefault:"5432"`
-	PostgresName     string `json:"postgresName" env:"POSTGRES_NAME"`
-	PostgresUser     string `json:"postgresUser" env:"POSTGRES_USER"`
-	PostgresPassword string `json:"postgresPassword" env:"POSTGRES_PASSWORD"`
+	Log  xbcfg.LogConfig  `json:"log"`
+	HTTP xbcfg.HTTPConfig `json:"http"`
+
+	PostgresHost     string        `json:"postgresHost" env:"POSTGRES_HOST" validate:"requiredModule:postgres"`
+	PostgresPort     string        `json:"postgresPort" env:"POSTGRES_PORT" envDefault:"5432" validate:"requiredModule:postgres"`
+	PostgresName     string        `json:"postgresName" env:"POSTGRES_NAME" validate:"requiredModule:postgres"`
+	PostgresUser     string        `json:"postgresUser" env:"POSTGRES_USER" validate:"requiredModule:postgres"`
+	PostgresPassword xbtype.Secret `json:"postgresPassword" env:"POSTGRES_PASSWORD"`
+
+	file     string
+	sources  map[string]string
+	sections map[reflect.Type]any
+	errs     []error
+}
+
+func (config *Config) Validate() *ConfigError {
+	return ValidateConfig(config)
 }
 
 // Base definition
@@ -129,6 +220,10 @@ func (config *Config) GetServiceLogLevel() string {
 	return config.ServiceLogLevel
 }
 
+func (config *Config) GetServiceErrorStack() bool {
+	return config.ServiceErrorStack
+}
+
 func (config *Config) GetServiceTesting() bool {
 	return config.ServiceTesting
 }
@@ -160,24 +255,166 @@ func (config *Config) GetPostgresUser() string {
 }
 
 func (config *Config) GetPostgresPassword() string {
-	return config.PostgresPassword
+	return config.PostgresPassword.Reveal()
+}
+
+func (config *Config) GetFile() string {
+	return config.file
+}
+
+func (config *Config) GetSources() map[string]string {
+	return config.sources
+}
+
+func (config *Config) GetSection(sectionType reflect.Type) (any, bool) {
+	section, ok := config.sections[sectionType]
+	return section, ok
+}
+
+func (config *Config) getErrors() []error {
+	return config.errs
+}
+
+func (config *Config) getBase() *Config {
+	return config
+}
+
+type baseConfig interface {
+	getBase() *Config
 }
 
 type configBuilder struct {
-	config *Config
+	target  xbcfg.Config
+	config  *Config
+	options *ConfigOptions
+	sources configSources
 }
 
-func (builder *configBuilder) build() *Config {
-	return builder.config
+func (builder *configBuilder) build() xbcfg.Config {
+	return builder.target
 }
 
 func (builder *configBuilder) initialize() *configBuilder {
-	builder.config = &Config{}
+	if base, ok := builder.target.(baseConfig); ok {
+		builder.config = base.getBase()
+	} else {
+		panic(fmt.Sprintf("Config `%T` must embed `xbprecfg.Config`.", builder.target))
+	}
+	builder.config.sources = map[string]string{}
+	if builder.options == nil {
+		builder.options = &ConfigOptions{}
+	}
+	return builder
+}
+
+func (builder *configBuilder) loadFile() *configBuilder {
+	path := builder.options.FilePath
+	if path == nil {
+		dotenv, _ := readDotenvFiles(builder.makeDotenvPaths())
+		for _, values := range []map[string]string{builder.options.FlagValues, builder.makeEnvironment(), dotenv} {
+			if value, ok := values[ConfigFileKey]; ok {
+				path = &value
+				break
+			}
+		}
+	}
+	if path != nil && *path != "" {
+		builder.config.file = *path
+		values, err := readConfigFile(*path, makeConfigKeyMap(builder.target))
+		builder.addError(err)
+		builder.addSource(SourceFile, values)
+	}
+	return builder
+}
+
+func (builder *configBuilder) loadDotenv() *configBuilder {
+	values, err := readDotenvFiles(builder.makeDotenvPaths())
+	builder.addError(err)
+	builder.addSource(SourceDotenv, values)
+	return builder
+}
+
+func (builder *configBuilder) loadEnv() *configBuilder {
+	builder.addSource(SourceEnv, builder.makeEnvironment())
+	return builder
+}
+
+func (builder *configBuilder) loadFlags() *configBuilder {
+	builder.addSource(SourceFlag, builder.options.FlagValues)
 	return builder
 }
 
+// Secrets may be given as paths through `<KEY>_FILE` (Docker and Kubernetes
+// secrets style), which are only read when `<KEY>` itself is not set.
+func (builder *configBuilder) loadSecretFiles() *configBuilder {
+	values := map[string]string{}
+	merged := builder.sources.merge()
+	iterateConfigFields(builder.target, func(field *configField) {
+		if field.field.Type != secretType || merged[field.key] != "" {
+			return
+		}
+		path, ok := merged[field.key+SecretFileSuffix]
+		if !ok || path == "" {
+			return
+		}
+		if data, err := os.ReadFile(path); err != nil {
+			builder.addError(fmt.Errorf("Config failed to read secret file `%s` of `%s`: %w", path, field.key, err))
+		} else {
+			values[field.key] = strings.TrimRight(string(data), "\r\n")
+		}
+	})
+	builder.addSource(SourceSecretFile, values)
+	return builder
+}
+
+func (builder *configBuilder) addSource(name string, values map[string]string) {
+	builder.sources = append(builder.sources, &configSource{name: name, values: values})
+}
+
+func (builder *configBuilder) addError(err error) {
+	if err != nil {
+		builder.config.errs = append(builder.config.errs, err)
+	}
+}
+
+func (builder *configBuilder) makeEnvironment() map[string]string {
+	environment := builder.options.Environment
+	if environment == nil {
+		environment = getEnvironment()
+	}
+	return environment
+}
+
+func (builder *configBuilder) makeDotenvPaths() []string {
+	paths := builder.options.DotenvPaths
+	if paths == nil {
+		paths = defaultDotenvPaths
+	}
+	return paths
+}
+
 func (builder *configBuilder) parseEnv() *configBuilder {
-	ParseEnv(builder.config)
+	keys := map[string]string{}
+	iterateConfigFields(builder.target, func(field *configField) {
+		keys[field.key] = field.path
+	})
+	err := env.ParseWithOptions(builder.target, env.Options{
+		Environment: builder.sources.merge(),
+		OnSet: func(key string, value any, isDefault bool) {
+			source := SourceDefault
+			if mSource, ok := builder.sources.lookup(key); ok && !isDefault {
+				source = mSource
+			}
+			builder.config.sources[keys[key]] = source
+		},
+	})
+	if aerr, ok := err.(env.AggregateError); ok {
+		for _, uerr := range aerr.Errors {
+			builder.addError(uerr)
+		}
+	} else {
+		builder.addError(err)
+	}
 	return builder
 }
 
@@ -192,6 +429,27 @@ func (builder *configBuilder) setServiceID() *configBuilder {
 }
 
 func (builder *configBuilder) setServiceDeveloping() *configBuilder {
-	builder.config.ServiceDeveloping = MakeServiceDeveloping(builder.config.ServiceEnvironment)
+	// An unsupported environment is reported by the `environment` validator.
+	builder.config.ServiceDeveloping = ServiceEnvironmentDevelopingMap[builder.config.ServiceEnvironment]
+	return builder
+}
+
+// Every struct field without an `env` tag in the config or the extended config
+// is a section.
+func (builder *configBuilder) setSections() *configBuilder {
+	sections := map[reflect.Type]any{}
+	for _, target := range []any{builder.config, builder.target} {
+		value := reflect.ValueOf(target).Elem()
+		for i := range value.NumField() {
+			field := value.Type().Field(i)
+			if !field.IsExported() || field.Anonymous || field.Type.Kind() != reflect.Struct {
+				continue
+			}
+			if _, ok := field.Tag.Lookup("env"); !ok {
+				sections[field.Type] = value.Field(i).Interface()
+			}
+		}
+	}
+	builder.config.sections = sections
 	return builder
 }
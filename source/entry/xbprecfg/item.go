@@ -0,0 +1,80 @@
+package xbprecfg
+
+import (
+	"fmt"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbtype"
+)
+
+// ConfigItem describes one `env` tagged field of a config, where the value of
+// a secret is always redacted.
+type ConfigItem struct {
+	Key      string
+	Path     string
+	Type     string
+	Value    string
+	Fallback string
+	Source   string
+	Rules    string
+	IsSecret bool
+
+	raw string
+}
+
+func ListConfigItems(config xbcfg.Config) []*ConfigItem {
+	sources := map[string]string{}
+	if config, ok := config.(SourceConfig); ok {
+		sources = config.GetSources()
+	}
+	items := []*ConfigItem{}
+	iterateConfigFields(config, func(field *configField) {
+		item := &ConfigItem{
+			Key:      field.key,
+			Path:     field.path,
+			Type:     field.field.Type.String(),
+			Value:    fmt.Sprint(field.value.Interface()),
+			Fallback: field.fallback,
+			Source:   sources[field.path],
+			Rules:    field.field.Tag.Get(ValidateTagName),
+			IsSecret: field.field.Type == secretType,
+		}
+		if item.IsSecret {
+			item.raw = field.value.Interface().(xbtype.Secret).Reveal()
+		} else {
+			item.raw = item.Value
+		}
+		items = append(items, item)
+	})
+	return items
+}
+
+type ConfigChange struct {
+	Key  string
+	Path string
+	Prev string
+	Next string
+}
+
+// DiffConfigs lists the items whose values differ between two configs. A
+// changed secret is reported without revealing either of its values.
+func DiffConfigs(prev, next xbcfg.Config) []*ConfigChange {
+	nextItems := map[string]*ConfigItem{}
+	for _, item := range ListConfigItems(next) {
+		nextItems[item.Key] = item
+	}
+	changes := []*ConfigChange{}
+	for _, prevItem := range ListConfigItems(prev) {
+		nextItem, ok := nextItems[prevItem.Key]
+		if !ok || nextItem.raw == prevItem.raw {
+			continue
+		}
+		changes = append(changes, &ConfigChange{
+			Key:  prevItem.Key,
+			Path: prevItem.Path,
+			Prev: prevItem.Value,
+			Next: nextItem.Value,
+		})
+	}
+	return changes
+}
@@ -0,0 +1,31 @@
+package xbprecfg
+
+import (
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
+)
+
+var mConfigOptions *ConfigOptions
+
+// ReloadConfig rebuilds the config from the sources it has been loaded from
+// and swaps it only when it is valid, so that a broken file or environment
+// never replaces a working config. The service ID is kept across reloads.
+func ReloadConfig() error {
+	config := LoadConfig(mConfigOptions)
+	if current, ok := xbcfg.GetConfig().(baseConfig); ok {
+		config.(baseConfig).getBase().ServiceID = current.getBase().ServiceID
+	}
+	if err := ValidateConfig(config); err != nil {
+		return err
+	}
+	xbcfg.SetConfig(config)
+	return nil
+}
+
+// GetConfigFile returns the config file in use, which is empty when the
+// config has only been loaded from the environment.
+func GetConfigFile() string {
+	if config, ok := xbcfg.GetConfig().(interface{ GetFile() string }); ok {
+		return config.GetFile()
+	}
+	return ""
+}
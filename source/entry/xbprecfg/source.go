@@ -0,0 +1,281 @@
+package xbprecfg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbtype"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/toolkit/xbvalue"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbjson"
+)
+
+// Sources are merged from the lowest to the highest precedence:
+// default < file < dotenv < env < flag < secretFile, where a secret file is
+// only used when no other source sets the secret itself.
+const (
+	SourceDefault    = "default"
+	SourceFile       = "file"
+	SourceDotenv     = "dotenv"
+	SourceEnv        = "env"
+	SourceFlag       = "flag"
+	SourceSecretFile = "secretFile"
+)
+
+const SecretFileSuffix = "_FILE"
+
+var secretType = reflect.TypeFor[xbtype.Secret]()
+
+const (
+	ConfigFileKey  = "SRV_CONFIG_FILE"
+	ConfigFileFlag = "config-file"
+)
+
+var defaultDotenvPaths = []string{".env"}
+
+var mDotenvValues = map[string]string{}
+
+// ExportDotenv exports the .env file into the process environment, and keeps
+// the exported values so that both sources can still be told apart by the
+// config.
+func ExportDotenv() error {
+	environment := env.ToMap(os.Environ())
+	if err := godotenv.Load(defaultDotenvPaths...); err != nil {
+		return err
+	}
+	for key, value := range env.ToMap(os.Environ()) {
+		if _, ok := environment[key]; !ok {
+			mDotenvValues[key] = value
+		}
+	}
+	return nil
+}
+
+// The real environment is read on every load, e.g. on a reload, leaving out
+// the values which have been exported from the .env file and kept as is.
+func getEnvironment() map[string]string {
+	environment := env.ToMap(os.Environ())
+	for key, value := range mDotenvValues {
+		if environment[key] == value {
+			delete(environment, key)
+		}
+	}
+	return environment
+}
+
+type SourceConfig interface {
+	xbcfg.Config
+	GetSources() map[string]string
+}
+
+type ConfigOptions struct {
+	FilePath    *string
+	DotenvPaths []string
+	Environment map[string]string
+	FlagValues  map[string]string
+}
+
+type configSource struct {
+	name   string
+	values map[string]string
+}
+
+type configSources []*configSource
+
+func (sources configSources) merge() map[string]string {
+	values := map[string]string{}
+	for _, source := range sources {
+		for key, value := range source.values {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+func (sources configSources) lookup(key string) (string, bool) {
+	for i := len(sources) - 1; i >= 0; i-- {
+		if _, ok := sources[i].values[key]; ok {
+			return sources[i].name, true
+		}
+	}
+	return "", false
+}
+
+type configField struct {
+	key      string
+	name     string
+	path     string
+	fallback string
+	value    reflect.Value
+	owner    reflect.Value
+	field    reflect.StructField
+}
+
+func iterateConfigFields(input any, operate func(field *configField)) {
+	value := reflect.ValueOf(input)
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	iterateStructFields(value, "", "", operate)
+}
+
+func iterateStructFields(value reflect.Value, prefix, path string, operate func(field *configField)) {
+	valueType := value.Type()
+	for i := range valueType.NumField() {
+		field := valueType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key, ok := field.Tag.Lookup("env")
+		if key == "-" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		if !ok && field.Type.Kind() == reflect.Struct {
+			subpath := path
+			if !field.Anonymous {
+				subpath = joinConfigPath(path, name)
+			}
+			iterateStructFields(value.Field(i), prefix+field.Tag.Get("envPrefix"), subpath, operate)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		operate(&configField{
+			key:      prefix + strings.Split(key, ",")[0],
+			name:     name,
+			path:     joinConfigPath(path, name),
+			fallback: field.Tag.Get("envDefault"),
+			value:    value.Field(i),
+			owner:    value,
+			field:    field,
+		})
+	}
+}
+
+func joinConfigPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func makeConfigKeyMap(input any) map[string]string {
+	keys := map[string]string{}
+	iterateConfigFields(input, func(field *configField) {
+		keys[field.path] = field.key
+	})
+	return keys
+}
+
+func readDotenvFiles(paths []string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if mValues, err := godotenv.Read(path); err != nil {
+			return values, fmt.Errorf("Config failed to read dotenv file `%s`: %w", path, err)
+		} else {
+			for key, value := range mValues {
+				values[key] = value
+			}
+		}
+	}
+	return values, nil
+}
+
+func readConfigFile(path string, keys map[string]string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Config failed to read file `%s`: %w", path, err)
+	}
+	tree := map[string]any{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	case ".json":
+		err = xbjson.Unmarshal(data, &tree)
+	default:
+		err = fmt.Errorf("unsupported config file extension `%s`", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Config failed to parse file `%s`: %w", path, err)
+	}
+	values := map[string]string{}
+	flattenConfigTree(tree, "", func(path string, value any) {
+		if key, ok := keys[path]; ok {
+			values[key] = fmt.Sprint(value)
+		} else {
+			values[path] = fmt.Sprint(value)
+		}
+	})
+	return values, nil
+}
+
+func flattenConfigTree(tree map[string]any, path string, operate func(path string, value any)) {
+	for name, value := range tree {
+		subpath := joinConfigPath(path, name)
+		if subtree, ok := value.(map[string]any); ok {
+			flattenConfigTree(subtree, subpath, operate)
+		} else {
+			operate(subpath, value)
+		}
+	}
+}
+
+func makeFlagName(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", "-"))
+}
+
+// MakeFlags derives one CLI flag per `env` tagged field of the config, e.g.
+// `SRV_PORT` becomes `--srv-port`. Secrets are left out since command lines
+// are visible to every process.
+func MakeFlags() []cli.Flag {
+	flags := []cli.Flag{
+		&cli.StringFlag{
+			Name:  ConfigFileFlag,
+			Usage: fmt.Sprintf("Load configuration from a YAML, TOML or JSON file (overrides %s)", ConfigFileKey),
+		},
+	}
+	iterateConfigFields(mConfigTemplate, func(field *configField) {
+		if field.field.Type == secretType {
+			return
+		}
+		flags = append(flags, &cli.StringFlag{
+			Name:  makeFlagName(field.key),
+			Usage: fmt.Sprintf("Override `%s` (%s)", field.key, field.path),
+		})
+	})
+	return flags
+}
+
+// ApplyFlags sets up the config once the CLI flags have been parsed, and
+// returns its problems instead of setting an invalid config.
+func ApplyFlags(ctx *cli.Context) error {
+	options := &ConfigOptions{FlagValues: map[string]string{}}
+	if ctx.IsSet(ConfigFileFlag) {
+		options.FilePath = xbvalue.Refer(ctx.String(ConfigFileFlag))
+	}
+	iterateConfigFields(mConfigTemplate, func(field *configField) {
+		if name := makeFlagName(field.key); field.field.Type != secretType && ctx.IsSet(name) {
+			options.FlagValues[field.key] = ctx.String(name)
+		}
+	})
+	return SetupConfig(options)
+}
@@ -0,0 +1,281 @@
+package xbprecfg
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/sirupsen/logrus"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/toolkit/xbvalue"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xberror"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbtag"
+)
+
+const ValidateTagName = "validate"
+
+// Validator checks a field value against the items following the rule name,
+// e.g. `range:1,65535` is given `[]string{"1", "65535"}`.
+type Validator = func(value reflect.Value, items []string) error
+
+var validatorMap = map[string]Validator{
+	"required":       validateRequired,
+	"requiredModule": validateRequiredModule,
+	"range":          validateRange,
+	"enum":           validateEnum,
+	"logLevel":       validateLogLevel,
+	"logOutputs":     validateLogOutputs,
+	"packageLevels":  validatePackageLevels,
+	"sampleLevels":   validateSampleLevels,
+	"locale":         validateLocale,
+	"environment":    validateEnvironment,
+}
+
+func RegisterValidator(name string, validator Validator) {
+	if _, ok := validatorMap[name]; ok {
+		panic(fmt.Sprintf("Duplicate config validator `%s` is found.", name))
+	}
+	validatorMap[name] = validator
+}
+
+// ValidateConfig checks every `validate` tagged field and returns all the
+// problems at once, including the ones met while loading the config.
+func ValidateConfig(config xbcfg.Config) *ConfigError {
+	errs := []error{}
+	if config, ok := config.(erroneousConfig); ok {
+		errs = append(errs, config.getErrors()...)
+	}
+	tagMaps := map[reflect.Type]map[string]*xbtag.StructFieldTag{}
+	iterateConfigFields(config, func(field *configField) {
+		ownerType := field.owner.Type()
+		if _, ok := tagMaps[ownerType]; !ok {
+			tagMaps[ownerType] = xbtag.ParseStructTag(ValidateTagName, field.owner.Interface(),
+				&xbtag.StructTagParserOptions{InfoSeparator: xbvalue.Refer(",")})
+		}
+		tag, ok := tagMaps[ownerType][field.field.Name]
+		if !ok {
+			return
+		}
+		for _, name := range tag.GetNames() {
+			validator, ok := validatorMap[name]
+			if !ok {
+				panic(fmt.Sprintf("Config validator `%s` hasn't been registered.", name))
+			}
+			items, _ := tag.GetItems(name)
+			if err := validator(field.value, items); err != nil {
+				errs = append(errs, &ConfigFieldError{key: field.key, path: field.path, rule: name, err: err})
+			}
+		}
+	})
+	if len(errs) == 0 {
+		return nil
+	}
+	return newConfigError("Config is invalid.", errs)
+}
+
+type erroneousConfig interface {
+	getErrors() []error
+}
+
+type ConfigError struct {
+	*xberror.WrapError
+	message string
+}
+
+func newConfigError(message string, errs []error) *ConfigError {
+	return &ConfigError{
+		WrapError: xberror.Wrap(message, errs...),
+		message:   message,
+	}
+}
+
+func (err *ConfigError) Error() string {
+	return fmt.Sprintf("<ConfigError| %s> is caused from: %v", err.message, err.Unwrap())
+}
+
+func (err *ConfigError) Report() string {
+	lines := []string{err.message}
+	for _, uerr := range err.Unwrap() {
+		lines = append(lines, fmt.Sprintf("  - %s", makeErrorText(uerr)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+type ConfigFieldError struct {
+	key  string
+	path string
+	rule string
+	err  error
+}
+
+func (err *ConfigFieldError) Error() string {
+	return fmt.Sprintf("<ConfigFieldError| `%s` (%s) fails rule `%s`> is caused from: %v",
+		err.key, err.path, err.rule, err.err)
+}
+
+func (err *ConfigFieldError) Unwrap() error {
+	return err.err
+}
+
+func (err *ConfigFieldError) GetKey() string {
+	return err.key
+}
+
+func (err *ConfigFieldError) GetPath() string {
+	return err.path
+}
+
+func (err *ConfigFieldError) GetRule() string {
+	return err.rule
+}
+
+func makeErrorText(err error) string {
+	switch err := err.(type) {
+	case *ConfigFieldError:
+		return fmt.Sprintf("`%s` (%s) %s", err.key, err.path, err.err.Error())
+	case env.AggregateError:
+		texts := make([]string, len(err.Errors))
+		for i, uerr := range err.Errors {
+			texts[i] = uerr.Error()
+		}
+		return strings.Join(texts, "; ")
+	}
+	return err.Error()
+}
+
+func validateRequired(value reflect.Value, items []string) error {
+	if value.IsZero() {
+		return fmt.Errorf("must be set")
+	}
+	return nil
+}
+
+func validateRequiredModule(value reflect.Value, items []string) error {
+	for _, item := range items {
+		if xbcfg.HasModule(item) && value.IsZero() {
+			return fmt.Errorf("must be set when module `%s` is used", item)
+		}
+	}
+	return nil
+}
+
+func validateRange(value reflect.Value, items []string) error {
+	if len(items) != 2 {
+		panic(fmt.Sprintf("Config validator `range` requires 2 items but got `%v`.", items))
+	}
+	lower, lerr := strconv.ParseFloat(items[0], 64)
+	upper, uerr := strconv.ParseFloat(items[1], 64)
+	if lerr != nil || uerr != nil {
+		panic(fmt.Sprintf("Config validator `range` requires numeric items but got `%v`.", items))
+	}
+	var number float64
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		number = value.Float()
+	default:
+		panic(fmt.Sprintf("Config validator `range` doesn't support kind `%s`.", value.Kind()))
+	}
+	if number < lower || number > upper {
+		return fmt.Errorf("must be between %s and %s but got `%v`", items[0], items[1], value.Interface())
+	}
+	return nil
+}
+
+func validateEnum(value reflect.Value, items []string) error {
+	if text := fmt.Sprint(value.Interface()); !slices.Contains(items, text) {
+		return fmt.Errorf("must be one of `%s` but got `%s`", strings.Join(items, "|"), text)
+	}
+	return nil
+}
+
+func validateLogLevel(value reflect.Value, items []string) error {
+	if _, err := logrus.ParseLevel(value.String()); err != nil {
+		return fmt.Errorf("must be a valid log level but got `%s`", value.String())
+	}
+	return nil
+}
+
+// The outputs are parsed by `xblogger`, which can't be imported here since it
+// requires the config to be set, so only their shape is checked.
+func validateLogOutputs(value reflect.Value, items []string) error {
+	kinds := []string{"stdout", "stderr", "file", "syslog"}
+	for _, item := range strings.Split(value.String(), ",") {
+		item = strings.TrimSpace(item)
+		if index := strings.LastIndex(item, "@"); index >= 0 {
+			if _, err := logrus.ParseLevel(item[index+1:]); err != nil {
+				return fmt.Errorf("must have valid levels but got `%s`", item)
+			}
+			item = item[:index]
+		}
+		kind, target, _ := strings.Cut(item, ":")
+		if !slices.Contains(kinds, kind) {
+			return fmt.Errorf("must be made of `%s` but got `%s`", strings.Join(kinds, "|"), item)
+		}
+		if kind == "file" && target == "" {
+			return fmt.Errorf("must have a path for file output but got `%s`", item)
+		}
+	}
+	return nil
+}
+
+func validateSampleLevels(value reflect.Value, items []string) error {
+	for _, item := range strings.Split(value.String(), ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		level, rule, ok := strings.Cut(item, "=")
+		if !ok || strings.Count(rule, "/") > 1 {
+			return fmt.Errorf("must be made of `level=first[/every]` but got `%s`", item)
+		}
+		if _, err := logrus.ParseLevel(strings.TrimSpace(level)); err != nil {
+			return fmt.Errorf("must have valid levels but got `%s`", item)
+		}
+		for _, count := range strings.Split(rule, "/") {
+			if number, err := strconv.Atoi(strings.TrimSpace(count)); err != nil || number < 0 {
+				return fmt.Errorf("must have non-negative counts but got `%s`", item)
+			}
+		}
+	}
+	return nil
+}
+
+func validatePackageLevels(value reflect.Value, items []string) error {
+	for _, item := range strings.Split(value.String(), ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		pattern, level, ok := strings.Cut(item, "=")
+		if !ok || strings.Trim(pattern, "/ ") == "" {
+			return fmt.Errorf("must be made of `pattern=level` but got `%s`", item)
+		}
+		if _, err := logrus.ParseLevel(strings.TrimSpace(level)); err != nil {
+			return fmt.Errorf("must have valid levels but got `%s`", item)
+		}
+	}
+	return nil
+}
+
+var localeRegex = regexp.MustCompile(`^[A-Za-z]{2,8}([-_][A-Za-z0-9]{1,8})*$`)
+
+func validateLocale(value reflect.Value, items []string) error {
+	if !localeRegex.MatchString(value.String()) {
+		return fmt.Errorf("must be a language tag, e.g. `en` or `zh-TW`, but got `%s`", value.String())
+	}
+	return nil
+}
+
+func validateEnvironment(value reflect.Value, items []string) error {
+	if _, ok := ServiceEnvironmentDevelopingMap[value.String()]; !ok {
+		return fmt.Errorf("must be a supported service environment but got `%s`", value.String())
+	}
+	return nil
+}
@@ -3,15 +3,33 @@ package xbpreset
 import (
 	"fmt"
 
-	"github.com/joho/godotenv"
-
 	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
 	"github.com/starryck/strk-tc-x-lib-go/source/entry/xbprecfg"
+	"github.com/starryck/strk-tc-x-lib-go/source/utility/xbspvs"
 )
 
+// The config is only loaded when it is first used, or by `Setup`, so that an
+// extended config can still be chosen in the `init` of `main`.
 func init() {
-	if err := godotenv.Load(); err == nil {
+	if err := xbprecfg.ExportDotenv(); err == nil {
 		fmt.Println("[INFO] The .env file has been successfully loaded.")
 	}
-	xbcfg.SetConfig(xbprecfg.NewConfig())
+	xbcfg.SetConfigLoader(loadConfig)
+	xbspvs.HandleReload(xbprecfg.ReloadConfig)
+}
+
+// loadConfig refuses an invalid config, so that a service never starts with
+// it silently.
+func loadConfig() xbcfg.Config {
+	config := xbprecfg.LoadConfig(nil)
+	if err := xbprecfg.ValidateConfig(config); err != nil {
+		panic(err.Report())
+	}
+	return config
+}
+
+// Setup loads the config explicitly and returns its problems, instead of
+// loading it on the first use.
+func Setup() error {
+	return xbprecfg.SetupConfig(nil)
 }
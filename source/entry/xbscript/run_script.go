@@ -1,5 +1,201 @@
 package xbscript
 
-func Execute() error {
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xblogger"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbtrace"
+	"github.com/starryck/strk-tc-x-lib-go/source/utility/xbflow"
+)
+
+const (
+	DryRunFlag  = "dry-run"
+	ListCommand = "list"
+)
+
+const flushTimeout = 5 * time.Second
+
+const (
+	ExitCodeFailed     = 1
+	ExitCodeUnprepared = 2
+)
+
+var mScriptMap = map[string]*Script{}
+
+type Script struct {
+	Name         string
+	Usage        string
+	Description  string
+	Flags        []cli.Flag
+	Dependencies []*Dependency
+	Operate      Operate
+}
+
+type Operate = func(flow *Flow) error
+
+// Dependency is prepared before the script runs, e.g.
+// `&xbscript.Dependency{Name: "postgres", Prepare: xbgorm.PreparePostgresClient}`.
+type Dependency struct {
+	Name    string
+	Prepare func() error
+}
+
+// Register makes a script available as `run-script <name>`. It is meant to be
+// called from the `init` function of the package defining the script.
+func Register(script *Script) {
+	if script.Name == "" || script.Name == ListCommand {
+		panic(fmt.Sprintf("Script name `%s` is invalid.", script.Name))
+	}
+	if script.Operate == nil {
+		panic(fmt.Sprintf("Script `%s` must have an operate function.", script.Name))
+	}
+	if _, ok := mScriptMap[script.Name]; ok {
+		panic(fmt.Sprintf("Duplicate script `%s` is found.", script.Name))
+	}
+	mScriptMap[script.Name] = script
+}
+
+func GetScripts() []*Script {
+	scripts := make([]*Script, 0, len(mScriptMap))
+	for _, script := range mScriptMap {
+		scripts = append(scripts, script)
+	}
+	slices.SortFunc(scripts, func(a, b *Script) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return scripts
+}
+
+func LookupScript(name string) (*Script, bool) {
+	script, ok := mScriptMap[name]
+	return script, ok
+}
+
+// MakeCommands derives the `run-script` subcommands from the registered
+// scripts, with a `--dry-run` flag added to each of them.
+func MakeCommands() []*cli.Command {
+	commands := []*cli.Command{
+		&cli.Command{
+			Name:     ListCommand,
+			Usage:    "List the registered scripts",
+			HelpName: fmt.Sprintf("run-script %s", ListCommand),
+			Action: func(ctx *cli.Context) error {
+				return List()
+			},
+		},
+	}
+	for _, script := range GetScripts() {
+		flags := append([]cli.Flag{
+			&cli.BoolFlag{
+				Name:  DryRunFlag,
+				Usage: "Run the script without applying any change",
+			},
+		}, script.Flags...)
+		commands = append(commands, &cli.Command{
+			Name:        script.Name,
+			Usage:       script.Usage,
+			Description: script.Description,
+			HelpName:    fmt.Sprintf("run-script %s", script.Name),
+			ArgsUsage:   "[arguments...]",
+			Flags:       flags,
+			Action: func(ctx *cli.Context) error {
+				return Run(script, ctx)
+			},
+		})
+	}
+	return commands
+}
+
+// Execute handles `run-script` when no registered script matches: the scripts
+// are listed without arguments, otherwise the unknown name is reported.
+func Execute(ctx *cli.Context) error {
+	if ctx.NArg() == 0 {
+		return List()
+	}
+	return cli.Exit(fmt.Sprintf("Script `%s` hasn't been registered.", ctx.Args().First()), ExitCodeFailed)
+}
+
+func List() error {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tDEPENDENCIES\tUSAGE")
+	for _, script := range GetScripts() {
+		names := make([]string, len(script.Dependencies))
+		for i, dependency := range script.Dependencies {
+			names[i] = dependency.Name
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", script.Name, strings.Join(names, ","), script.Usage)
+	}
+	return writer.Flush()
+}
+
+func Run(script *Script, ctx *cli.Context) error {
+	flow := &Flow{script: script, ctx: ctx, dryRun: ctx.Bool(DryRunFlag)}
+	flow.Initiate()
+	defer xblogger.FlushWithTimeout(flushTimeout)
+	defer xbtrace.FlushWithTimeout(flushTimeout)
+	span := flow.GetSpan()
+	span.SetName(fmt.Sprintf("script %s", script.Name))
+	defer span.End()
+	logger := flow.GetLogger().WithFields(xblogger.Fields{
+		"script": script.Name,
+		"dryRun": flow.dryRun,
+	})
+	for _, dependency := range script.Dependencies {
+		if err := dependency.Prepare(); err != nil {
+			logger.WithError(err).Errorf("Script dependency `%s` failed to be prepared.", dependency.Name)
+			return cli.Exit("", ExitCodeUnprepared)
+		}
+	}
+	logger.Info("Script started.")
+	startTime := time.Now()
+	err := flow.operate()
+	logger = logger.WithField("duration", time.Since(startTime).String())
+	if err != nil {
+		span.SetError(err)
+		logger.WithError(err).Error("Script failed.")
+		if coder, ok := err.(cli.ExitCoder); ok {
+			return cli.Exit("", coder.ExitCode())
+		}
+		return cli.Exit("", ExitCodeFailed)
+	}
+	logger.Info("Script finished.")
 	return nil
 }
+
+type Flow struct {
+	xbflow.BaseFlow
+	script *Script
+	ctx    *cli.Context
+	dryRun bool
+}
+
+func (flow *Flow) GetScript() *Script {
+	return flow.script
+}
+
+func (flow *Flow) GetContext() *cli.Context {
+	return flow.ctx
+}
+
+func (flow *Flow) IsDryRun() bool {
+	return flow.dryRun
+}
+
+func (flow *Flow) operate() (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			flow.GetLogger().WithField(xblogger.PanicKey, xblogger.FormatPanic(v, debug.Stack())).Error("Script panicked.")
+			err = fmt.Errorf("Script `%s` panicked: %v", flow.script.Name, v)
+		}
+	}()
+	err = flow.script.Operate(flow)
+	return
+}
@@ -0,0 +1,110 @@
+package xbgin
+
+import (
+	"net/http"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbconst"
+)
+
+const MIMEProblemJSON = "application/problem+json"
+
+const defaultProblemTypeBase = "urn:problem-type:"
+
+// problemTitleMap names the non-standard statuses, which `http.StatusText`
+// leaves empty.
+var problemTitleMap = map[int]string{
+	499: "Client Closed Request",
+}
+
+var (
+	EnvelopeFormat ResponseFormat = &envelopeFormat{}
+	ProblemFormat  ResponseFormat = NewProblemFormat(nil)
+)
+
+// ResponseFormat renders the meta message responses of a flow, i.e. the ones
+// of `RESTFlow.RespondJSON` and the errors set by `ResponseMiddleware`.
+type ResponseFormat interface {
+	Respond(flow *RESTFlow, message *MetaMessage, data any, options *JSONResponseOptions)
+}
+
+// NewResponseFormatMiddleware selects the response format of the routes it
+// precedes, e.g. within the handlers of a `RouterStem`, instead of the meta/data
+// envelope.
+func NewResponseFormatMiddleware(format ResponseFormat) Handler {
+	return func(ctx *Context) {
+		ctx.Set(xbconst.ContextResponseFormat, format)
+		ctx.Next()
+	}
+}
+
+// SetResponseFormat selects the response format of every route, including the
+// unmatched ones, which the route groups may still override. It is applied by
+// the middlewares of `NewMiddlewares`, so it must be called before them.
+func (router *Router) SetResponseFormat(format ResponseFormat) {
+	router.responseFormat = format
+}
+
+type envelopeFormat struct{}
+
+func (format *envelopeFormat) Respond(flow *RESTFlow, message *MetaMessage, data any, options *JSONResponseOptions) {
+	response := NewJSONResponse(message, data, options)
+	flow.SetHeader(xbconst.HeaderContentLanguage, response.Locale)
+	flow.context.JSON(response.Code, response)
+	return
+}
+
+type ProblemFormatOptions struct {
+	TypeBase *string
+	Extend   func(flow *RESTFlow, problem *ProblemResponse)
+}
+
+// problemFormat renders the error responses, i.e. of status 4xx or 5xx, as the
+// RFC 7807 problem details, and the other ones as the meta/data envelope.
+type problemFormat struct {
+	typeBase string
+	extend   func(flow *RESTFlow, problem *ProblemResponse)
+}
+
+// NewProblemFormat makes a problem details format, whose problem types are the
+// out codes following the type base, e.g. `urn:problem-type:S001-WMV404`.
+// The extend function may add the extension members of the problems.
+func NewProblemFormat(options *ProblemFormatOptions) ResponseFormat {
+	if options == nil {
+		options = &ProblemFormatOptions{}
+	}
+	format := &problemFormat{typeBase: defaultProblemTypeBase, extend: options.Extend}
+	if options.TypeBase != nil {
+		format.typeBase = *options.TypeBase
+	}
+	return format
+}
+
+func (format *problemFormat) Respond(flow *RESTFlow, message *MetaMessage, data any, options *JSONResponseOptions) {
+	if options == nil {
+		options = &JSONResponseOptions{}
+	}
+	status := message.GetHTTPCode()
+	if options.HTTPCode != nil {
+		status = *options.HTTPCode
+	}
+	if status < http.StatusBadRequest {
+		EnvelopeFormat.Respond(flow, message, data, options)
+		return
+	}
+	instance := flow.GetID()
+	problem := NewProblemResponse(message, &ProblemResponseOptions{
+		HTTPCode: options.HTTPCode,
+		MetaArgs: options.MetaArgs,
+		Details:  options.Details,
+		Locales:  options.Locales,
+		TypeBase: &format.typeBase,
+		Instance: &instance,
+	})
+	if format.extend != nil {
+		format.extend(flow, problem)
+	}
+	flow.SetHeader(xbconst.HeaderContentLanguage, problem.Locale)
+	flow.SetHeader(xbconst.HeaderContentType, MIMEProblemJSON)
+	flow.context.JSON(problem.Status, problem)
+	return
+}
@@ -0,0 +1,215 @@
+package xbgin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbmtmsg"
+	"github.com/starryck/strk-tc-x-lib-go/source/utility/xbspvs"
+)
+
+const (
+	HealthStatusUp   = "up"
+	HealthStatusDown = "down"
+)
+
+const (
+	defaultLivenessPath  = "/health/live"
+	defaultReadinessPath = "/health/ready"
+	defaultInfoPath      = "/info"
+	defaultCheckTimeout  = 5 * time.Second
+)
+
+// Checker reports whether a dependency is ready to serve, e.g.
+// `xbgorm.CheckPostgresClient`.
+type Checker = func(ctx context.Context) error
+
+type HealthData struct {
+	Status string                      `json:"status"`
+	Checks map[string]*HealthCheckData `json:"checks,omitempty"`
+}
+
+type HealthCheckData struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type InfoData struct {
+	GitTag         string `json:"gitTag"`
+	GitCommit      string `json:"gitCommit"`
+	ServiceCode    string `json:"serviceCode"`
+	ServiceName    string `json:"serviceName"`
+	ServiceVersion string `json:"serviceVersion"`
+}
+
+// HandleCheck registers a readiness checker. The supervisor shutdown state is
+// always checked.
+func (router *Router) HandleCheck(name string, checker Checker) {
+	if _, ok := router.checkers[name]; ok {
+		panic(fmt.Sprintf("Duplicate readiness checker `%s` is found.", name))
+	}
+	router.checkers[name] = checker
+}
+
+// UseHealth registers the liveness, readiness and info routes in a group of
+// the given handlers. Like any route, they only take the middlewares set
+// before, so it is meant to be called after `UseMiddlewares`.
+func (router *Router) UseHealth(options *HealthOptions) {
+	health := (&healthBuilder{router: router, options: options}).
+		initialize().
+		setHandlers().
+		setLivenessPath().
+		setReadinessPath().
+		setInfoPath().
+		setCheckTimeout().
+		build()
+	group := router.engine.Group("", health.handlers...)
+	group.GET(health.livenessPath, health.handleLiveness)
+	group.GET(health.readinessPath, health.handleReadiness)
+	group.GET(health.infoPath, health.handleInfo)
+}
+
+type health struct {
+	router        *Router
+	handlers      []Handler
+	livenessPath  string
+	readinessPath string
+	infoPath      string
+	checkTimeout  time.Duration
+}
+
+func (health *health) handleLiveness(ctx *Context) {
+	flow := &RESTFlow{}
+	flow.Initiate(ctx)
+	flow.RespondJSON(xbmtmsg.IMV200, &HealthData{Status: HealthStatusUp}, nil)
+}
+
+func (health *health) handleReadiness(ctx *Context) {
+	flow := &RESTFlow{}
+	flow.Initiate(ctx)
+	data := health.makeHealthData(ctx.Request.Context())
+	if data.Status == HealthStatusUp {
+		flow.RespondJSON(xbmtmsg.IMV200, data, nil)
+	} else {
+		flow.RespondJSON(xbmtmsg.EMV503, data, nil)
+	}
+}
+
+func (health *health) handleInfo(ctx *Context) {
+	flow := &RESTFlow{}
+	flow.Initiate(ctx)
+	flow.RespondJSON(xbmtmsg.IMV200, &InfoData{
+		GitTag:         xbcfg.GetGitTag(),
+		GitCommit:      xbcfg.GetGitCommit(),
+		ServiceCode:    xbcfg.GetServiceCode(),
+		ServiceName:    xbcfg.GetServiceName(),
+		ServiceVersion: xbcfg.GetServiceVersion(),
+	}, nil)
+}
+
+func (health *health) makeHealthData(ctx context.Context) *HealthData {
+	ctx, cancel := context.WithTimeout(ctx, health.checkTimeout)
+	defer cancel()
+	data := &HealthData{Status: HealthStatusUp, Checks: map[string]*HealthCheckData{}}
+	mutex := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	for name, checker := range health.router.checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			check := &HealthCheckData{Status: HealthStatusUp}
+			if err := checker(ctx); err != nil {
+				check.Status = HealthStatusDown
+				check.Error = err.Error()
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			data.Checks[name] = check
+			if check.Status == HealthStatusDown {
+				data.Status = HealthStatusDown
+			}
+		}()
+	}
+	wg.Wait()
+	return data
+}
+
+type healthBuilder struct {
+	router  *Router
+	health  *health
+	options *HealthOptions
+}
+
+type HealthOptions struct {
+	Handlers      []Handler
+	LivenessPath  *string
+	ReadinessPath *string
+	InfoPath      *string
+	CheckTimeout  *time.Duration
+}
+
+func (builder *healthBuilder) build() *health {
+	return builder.health
+}
+
+func (builder *healthBuilder) initialize() *healthBuilder {
+	builder.health = &health{router: builder.router}
+	if builder.options == nil {
+		builder.options = &HealthOptions{}
+	}
+	return builder
+}
+
+func (builder *healthBuilder) setHandlers() *healthBuilder {
+	builder.health.handlers = builder.options.Handlers
+	return builder
+}
+
+func (builder *healthBuilder) setLivenessPath() *healthBuilder {
+	livenessPath := builder.options.LivenessPath
+	if livenessPath != nil {
+		builder.health.livenessPath = *livenessPath
+	} else {
+		builder.health.livenessPath = defaultLivenessPath
+	}
+	return builder
+}
+
+func (builder *healthBuilder) setReadinessPath() *healthBuilder {
+	readinessPath := builder.options.ReadinessPath
+	if readinessPath != nil {
+		builder.health.readinessPath = *readinessPath
+	} else {
+		builder.health.readinessPath = defaultReadinessPath
+	}
+	return builder
+}
+
+func (builder *healthBuilder) setInfoPath() *healthBuilder {
+	infoPath := builder.options.InfoPath
+	if infoPath != nil {
+		builder.health.infoPath = *infoPath
+	} else {
+		builder.health.infoPath = defaultInfoPath
+	}
+	return builder
+}
+
+func (builder *healthBuilder) setCheckTimeout() *healthBuilder {
+	checkTimeout := builder.options.CheckTimeout
+	if checkTimeout != nil {
+		builder.health.checkTimeout = *checkTimeout
+	} else {
+		builder.health.checkTimeout = defaultCheckTimeout
+	}
+	return builder
+}
+
+func defaultCheckers() map[string]Checker {
+	return map[string]Checker{
+		"supervisor": xbspvs.CheckSupervisor,
+	}
+}
@@ -0,0 +1,101 @@
+package xbgin
+
+import (
+	"strings"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbmtmsg"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xberror"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xblogger"
+)
+
+const defaultLogLevelsPath = "/admin/log-levels"
+
+// LogLevelsOptions requires the authorization handler, which is run before
+// the other handlers of the routes.
+type LogLevelsOptions struct {
+	Path      *string
+	Authorize Handler
+	Handlers  []Handler
+}
+
+type LogLevelsData struct {
+	Level    string            `json:"level"`
+	Packages map[string]string `json:"packages"`
+}
+
+// LogLevelBody changes the service level, or the level of the packages
+// matched by the pattern when the package is given.
+type LogLevelBody struct {
+	Package string `json:"package"`
+	Level   string `json:"level" binding:"required"`
+}
+
+// UseLogLevels registers the routes to get, change and unset the log levels
+// at runtime, e.g. `DELETE /admin/log-levels?package=xbgorm`. The changes are
+// kept until the next config reload. It panics without the authorization
+// handler, since the routes change the logging of the whole service.
+func (router *Router) UseLogLevels(options *LogLevelsOptions) {
+	if options == nil || options.Authorize == nil {
+		panic("Log levels authorization handler hasn't been set.")
+	}
+	path := defaultLogLevelsPath
+	if options.Path != nil {
+		path = *options.Path
+	}
+	group := router.engine.Group(path, append([]Handler{options.Authorize}, options.Handlers...)...)
+	group.GET("", handleGetLogLevels)
+	group.PUT("", handlePutLogLevel)
+	group.DELETE("", handleDeleteLogLevel)
+}
+
+func handleGetLogLevels(ctx *Context) {
+	flow := &RESTFlow{}
+	flow.Initiate(ctx)
+	flow.RespondJSON(xbmtmsg.IMV200, makeLogLevelsData(), nil)
+}
+
+func handlePutLogLevel(ctx *Context) {
+	flow := &RESTFlow{}
+	flow.Initiate(ctx)
+	body := &LogLevelBody{}
+	flow.BindBody(body)
+	if flow.HasError() {
+		return
+	}
+	level, err := xblogger.ParseLevel(body.Level)
+	if err != nil {
+		flow.SetError(xberror.Validation(xbmtmsg.WMV453, &xberror.Options{
+			OutDetails: []*xberror.Detail{makeValidationDetail("level", "logLevel", "", body.Level)},
+			LogFields:  xblogger.Fields{"bindingValue": body},
+		}, err))
+		return
+	}
+	if body.Package == "" {
+		xblogger.SetLevel(level)
+	} else {
+		xblogger.SetPackageLevel(body.Package, level)
+	}
+	flow.GetLogger().WithField("package", body.Package).Infof("Logger changed level to `%s`.", level)
+	flow.RespondJSON(xbmtmsg.IMV200, makeLogLevelsData(), nil)
+}
+
+func handleDeleteLogLevel(ctx *Context) {
+	flow := &RESTFlow{}
+	flow.Initiate(ctx)
+	pattern := strings.Trim(flow.GetQuery("package"), "/ ")
+	if _, ok := xblogger.GetPackageLevels()[pattern]; !ok {
+		flow.SetNotFoundError()
+		return
+	}
+	xblogger.UnsetPackageLevel(pattern)
+	flow.GetLogger().WithField("package", pattern).Info("Logger unset package level.")
+	flow.RespondJSON(xbmtmsg.IMV200, makeLogLevelsData(), nil)
+}
+
+func makeLogLevelsData() *LogLevelsData {
+	data := &LogLevelsData{Level: xblogger.GetLevel().String(), Packages: map[string]string{}}
+	for pattern, level := range xblogger.GetPackageLevels() {
+		data.Packages[pattern] = level.String()
+	}
+	return data
+}
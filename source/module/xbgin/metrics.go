@@ -0,0 +1,37 @@
+package xbgin
+
+import (
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbmetric"
+)
+
+const defaultMetricsPath = "/metrics"
+
+type MetricsOptions struct {
+	Path     *string
+	Handlers []Handler
+	Registry *xbmetric.Registry
+}
+
+// UseMetrics exposes the metrics registry in the Prometheus text format, in a
+// group of the given handlers. Like any route, it only takes the middlewares
+// set before, so it is meant to be called after `UseMiddlewares`.
+func (router *Router) UseMetrics(options *MetricsOptions) {
+	if options == nil {
+		options = &MetricsOptions{}
+	}
+	path := defaultMetricsPath
+	if options.Path != nil {
+		path = *options.Path
+	}
+	registry := options.Registry
+	if registry == nil {
+		registry = xbmetric.GetRegistry()
+	}
+	group := router.engine.Group(path, options.Handlers...)
+	group.GET("", func(ctx *Context) {
+		ctx.Header("Content-Type", xbmetric.TextContentType)
+		if err := registry.WriteText(ctx.Writer); err != nil {
+			ctx.Error(err)
+		}
+	})
+}
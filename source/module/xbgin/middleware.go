@@ -2,8 +2,10 @@ package xbgin
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbconst"
@@ -11,6 +13,7 @@ import (
 	"github.com/starryck/strk-tc-x-lib-go/source/core/toolkit/xbslice"
 	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xberror"
 	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xblogger"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbmetric"
 	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbwatch"
 	"github.com/starryck/strk-tc-x-lib-go/source/utility/xbspvs"
 )
@@ -41,18 +44,30 @@ func (flow *GraceMiddlewareFlow) NextFlow() {
 	return
 }
 
+const unmatchedRoute = "unmatched"
+
+const retryAfterSeconds = "1"
+
 const (
 	maxRequestBodyReadSize   = 1 << 12
 	maxRequestBodyRecordSize = 1 << 16
 )
 
+var (
+	requestCounter = xbmetric.NewCounter("http_requests_total",
+		"Total number of handled HTTP requests.", "method", "route", "status")
+	requestDurationHistogram = xbmetric.NewHistogram("http_request_duration_seconds",
+		"Duration of handled HTTP requests in seconds.", xbmetric.DurationBuckets, "method", "route", "status")
+	responseSizeHistogram = xbmetric.NewHistogram("http_response_size_bytes",
+		"Size of HTTP responses in bytes.", xbmetric.SizeBuckets, "method", "route", "status")
+)
+
 func RecordMiddleware(ctx *Context) {
 	flow := &RecordMiddlewareFlow{}
 	flow.Initiate(ctx)
+	defer flow.Record()
 	flow.SetBodies()
 	flow.NextFlow()
-	flow.SetFields()
-	flow.SetResult()
 }
 
 type RecordMiddlewareFlow struct {
@@ -70,6 +85,26 @@ func (flow *RecordMiddlewareFlow) Initiate(ctx *Context) {
 	return
 }
 
+// Record must be deferred, where the panics of the following handlers are
+// recovered as `RecoveryMiddleware` does before the request is recorded with
+// the responded status. `http.ErrAbortHandler` is panicked again once recorded.
+func (flow *RecordMiddlewareFlow) Record() {
+	value := recover()
+	if value != nil && value != http.ErrAbortHandler {
+		recovery := &RecoveryMiddlewareFlow{}
+		recovery.Initiate(flow.GetContext())
+		recovery.Handle(value)
+	}
+	flow.SetFields()
+	flow.SetMetrics()
+	flow.EndSpan()
+	flow.SetResult()
+	if value == http.ErrAbortHandler {
+		panic(value)
+	}
+	return
+}
+
 func (flow *RecordMiddlewareFlow) SetBodies() {
 	request := flow.GetRequest()
 	buffer := &bytes.Buffer{}
@@ -104,6 +139,33 @@ func (flow *RecordMiddlewareFlow) SetFields() {
 	return
 }
 
+// SetMetrics labels the request by its route pattern rather than its path,
+// so that path parameters don't blow up the number of series.
+func (flow *RecordMiddlewareFlow) SetMetrics() {
+	method := flow.makeRequestMethod()
+	route := flow.makeRequestRoute()
+	status := strconv.Itoa(flow.makeResponseStatus())
+	requestCounter.Inc(method, route, status)
+	requestDurationHistogram.Observe(flow.watch.ElapsedTime().Seconds(), method, route, status)
+	responseSizeHistogram.Observe(float64(max(flow.makeResponseSize(), 0)), method, route, status)
+	return
+}
+
+func (flow *RecordMiddlewareFlow) EndSpan() {
+	span := flow.GetSpan()
+	status := flow.makeResponseStatus()
+	span.SetName(fmt.Sprintf("%s %s", flow.makeRequestMethod(), flow.makeRequestRoute()))
+	span.SetAttribute("http.request.method", flow.makeRequestMethod())
+	span.SetAttribute("http.route", flow.makeRequestRoute())
+	span.SetAttribute("http.response.status_code", status)
+	span.SetAttribute("url.path", flow.GetRequest().URL.Path)
+	if status >= http.StatusInternalServerError {
+		span.SetError(flow.GetError())
+	}
+	span.End()
+	return
+}
+
 func (flow *RecordMiddlewareFlow) makeRequestIP() string {
 	ip := flow.GetRequestIP()
 	return ip
@@ -119,6 +181,14 @@ func (flow *RecordMiddlewareFlow) makeRequestMethod() string {
 	return method
 }
 
+func (flow *RecordMiddlewareFlow) makeRequestRoute() string {
+	route := flow.GetContext().FullPath()
+	if route == "" {
+		route = unmatchedRoute
+	}
+	return route
+}
+
 func (flow *RecordMiddlewareFlow) makeRequestHandler() string {
 	handler := xbslice.Last(strings.Split(flow.GetContext().HandlerName(), "/"))
 	return handler
@@ -169,11 +239,17 @@ type ResponseMiddlewareFlow struct {
 	MiddlewareFlow
 }
 
+// SetResult responds a retryable error as unavailable for now, e.g. a timeout,
+// which the clients may retry after a while.
 func (flow *ResponseMiddlewareFlow) SetResult() {
-	err := flow.GetError()
+	err := xberror.Categorize(flow.GetError())
 	if cerr, ok := xberror.AsCustomError(err); ok {
+		if xberror.IsRetryable(err) && cerr.Message().GetHTTPCode() == http.StatusServiceUnavailable {
+			flow.SetHeader(xbconst.HeaderRetryAfter, retryAfterSeconds)
+		}
 		flow.RespondJSON(cerr.Message(), nil, &JSONResponseOptions{
 			MetaArgs: cerr.OutArgs(),
+			Details:  xberror.GetOutDetails(cerr),
 		})
 		return
 	}
@@ -0,0 +1,129 @@
+package xbgin
+
+import (
+	"maps"
+	"net/http"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xberror"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbjson"
+)
+
+func NewProblemResponse(message *MetaMessage, options *ProblemResponseOptions) *ProblemResponse {
+	response := (&problemResponseBuilder{message: message, options: options}).
+		initialize().
+		setStatus().
+		setType().
+		setTexts().
+		setInstance().
+		build()
+	return response
+}
+
+// ProblemResponse is the RFC 7807 problem details of an error response, whose
+// extension members are serialized along with the standard ones, e.g. `code`
+// and `errors` of the meta/data envelope.
+type ProblemResponse struct {
+	Locale     string
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]any
+}
+
+// MarshalJSON keeps the standard members over the extension ones of the same
+// names. The members are sorted, so that the output is stable.
+func (problem *ProblemResponse) MarshalJSON() ([]byte, error) {
+	members := maps.Clone(problem.Extensions)
+	if members == nil {
+		members = map[string]any{}
+	}
+	members["type"] = problem.Type
+	members["title"] = problem.Title
+	members["status"] = problem.Status
+	if problem.Detail != "" {
+		members["detail"] = problem.Detail
+	}
+	if problem.Instance != "" {
+		members["instance"] = problem.Instance
+	}
+	return xbjson.MarshalSorted(members)
+}
+
+type ProblemResponseOptions struct {
+	HTTPCode *int
+	MetaArgs []any
+	Details  []*xberror.Detail
+	Locales  []string
+	TypeBase *string
+	Instance *string
+}
+
+type problemResponseBuilder struct {
+	message  *MetaMessage
+	options  *ProblemResponseOptions
+	response *ProblemResponse
+}
+
+func (builder *problemResponseBuilder) build() *ProblemResponse {
+	return builder.response
+}
+
+func (builder *problemResponseBuilder) initialize() *problemResponseBuilder {
+	builder.response = &ProblemResponse{Extensions: map[string]any{}}
+	if builder.options == nil {
+		builder.options = &ProblemResponseOptions{}
+	}
+	return builder
+}
+
+func (builder *problemResponseBuilder) setStatus() *problemResponseBuilder {
+	if code := builder.options.HTTPCode; code != nil {
+		builder.response.Status = *code
+	} else {
+		builder.response.Status = builder.message.GetHTTPCode()
+	}
+	return builder
+}
+
+func (builder *problemResponseBuilder) setType() *problemResponseBuilder {
+	typeBase := defaultProblemTypeBase
+	if builder.options.TypeBase != nil {
+		typeBase = *builder.options.TypeBase
+	}
+	builder.response.Type = typeBase + builder.message.GetOutCode()
+	return builder
+}
+
+// setTexts takes the title of the status, which is the same for every
+// occurrence, or the out text of the meta message in the default locale for an
+// unnamed status, and the localized out text and details of the message.
+func (builder *problemResponseBuilder) setTexts() *problemResponseBuilder {
+	envelope := NewJSONResponse(builder.message, nil, &JSONResponseOptions{
+		MetaArgs: builder.options.MetaArgs,
+		Details:  builder.options.Details,
+		Locales:  builder.options.Locales,
+	})
+	builder.response.Locale = envelope.Locale
+	builder.response.Title = http.StatusText(builder.response.Status)
+	if title, ok := problemTitleMap[builder.response.Status]; ok {
+		builder.response.Title = title
+	}
+	if builder.response.Title == "" {
+		builder.response.Title = builder.message.GetOutText()
+	}
+	builder.response.Detail = envelope.Meta.(*JSONResponseMeta).Message
+	builder.response.Extensions["code"] = builder.message.GetOutCode()
+	if len(envelope.Errors) > 0 {
+		builder.response.Extensions["errors"] = envelope.Errors
+	}
+	return builder
+}
+
+func (builder *problemResponseBuilder) setInstance() *problemResponseBuilder {
+	if instance := builder.options.Instance; instance != nil {
+		builder.response.Instance = *instance
+	}
+	return builder
+}
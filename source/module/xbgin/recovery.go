@@ -0,0 +1,105 @@
+package xbgin
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"syscall"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbmtmsg"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xberror"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xblogger"
+)
+
+// CrashReporter is notified of the panics recovered from the requests, e.g. to
+// forward them to an error tracking service, along with the stack of the panic.
+type CrashReporter = func(flow *RESTFlow, err error, stack []byte)
+
+var crashReporterMap = map[string]CrashReporter{}
+
+func RegisterCrashReporter(name string, reporter CrashReporter) {
+	if _, ok := crashReporterMap[name]; ok {
+		panic(fmt.Sprintf("Duplicate crash reporter `%s` is found.", name))
+	}
+	crashReporterMap[name] = reporter
+}
+
+// RecoveryMiddleware turns the panics of the following handlers into unexpected
+// errors, which are logged and responded as `EMV500` within the flow, instead of
+// the empty response of `gin.Recovery`. The panics below `RecordMiddleware` are
+// recovered by it alike, so that the request is still recorded.
+func RecoveryMiddleware(ctx *Context) {
+	flow := &RecoveryMiddlewareFlow{}
+	flow.Initiate(ctx)
+	defer flow.Recover()
+	flow.NextFlow()
+}
+
+type RecoveryMiddlewareFlow struct {
+	MiddlewareFlow
+}
+
+// Recover must be deferred, where `http.ErrAbortHandler` is panicked again to
+// abort the response as `net/http` does, and a broken connection is left
+// without a response.
+func (flow *RecoveryMiddlewareFlow) Recover() {
+	value := recover()
+	if value == nil {
+		return
+	}
+	if value == http.ErrAbortHandler {
+		panic(value)
+	}
+	flow.Handle(value)
+	return
+}
+
+// Handle logs, reports and responds a recovered panic other than
+// `http.ErrAbortHandler`.
+func (flow *RecoveryMiddlewareFlow) Handle(value any) {
+	stack := debug.Stack()
+	err := flow.makePanicError(value)
+	flow.SetError(err)
+	flow.GetLogger().WithError(err).WithField(xblogger.PanicKey, xblogger.FormatPanic(value, stack)).Error("Request panicked.")
+	flow.report(err, stack)
+	flow.GetSpan().SetError(err)
+	if flow.isBrokenConnection(value) || flow.GetContext().Writer.Written() {
+		return
+	}
+	flow.RespondJSON(xbmtmsg.EMV500, nil, nil)
+	return
+}
+
+func (flow *RecoveryMiddlewareFlow) makePanicError(value any) error {
+	stack := true
+	options := &xberror.Options{Stack: &stack}
+	if err, ok := value.(error); ok {
+		return xberror.Unexpected(xbmtmsg.EMV500, options, err)
+	}
+	return xberror.Unexpected(xbmtmsg.EMV500, options, xberror.Newf("Request panicked: %v", []any{value}))
+}
+
+func (flow *RecoveryMiddlewareFlow) isBrokenConnection(value any) bool {
+	err, ok := value.(error)
+	return ok && (errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET))
+}
+
+// report shields the request from the panics of the reporters, which are only
+// logged.
+func (flow *RecoveryMiddlewareFlow) report(err error, stack []byte) {
+	for _, name := range slices.Sorted(maps.Keys(crashReporterMap)) {
+		func() {
+			defer func() {
+				if v := recover(); v != nil {
+					flow.GetLogger().WithField(xblogger.PanicKey, xblogger.FormatPanic(v, debug.Stack())).
+						Warningf("Crash reporter `%s` panicked.", name)
+				}
+			}()
+			crashReporterMap[name](&flow.RESTFlow, err, stack)
+		}()
+	}
+	return
+}
@@ -0,0 +1,124 @@
+package xbgin
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbconst"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/toolkit/xbrand"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xblogger"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbtrace"
+)
+
+const (
+	RequestIDFormatKSUID = "ksuid"
+	RequestIDFormatXID   = "xid"
+)
+
+const maxRequestIDLength = 1 << 7
+
+type requestIDContextKey struct{}
+
+// RequestIDMiddleware takes the request ID of the inbound header behind a
+// trusted proxy, see `HTTPConfig.RequestIDTrust`, or generates one otherwise,
+// and echoes it on the response. Without a trace context to continue, it also
+// becomes the flow ID, so that the clients can't choose the flow IDs of the
+// logs, while an untrusted inbound ID is only logged as `InboundRequestID`.
+func RequestIDMiddleware(ctx *Context) {
+	flow := &RequestIDMiddlewareFlow{}
+	flow.Initiate(ctx)
+	flow.SetRequestID()
+	flow.NextFlow()
+}
+
+type RequestIDMiddlewareFlow struct {
+	MiddlewareFlow
+}
+
+func (flow *RequestIDMiddlewareFlow) SetRequestID() {
+	config := xbcfg.GetHTTPConfig()
+	header := config.RequestIDHeader
+	id, fields := flow.GetHeader(header), xblogger.Fields{}
+	if !isValidRequestID(id) {
+		id = makeRequestID()
+	} else if !config.RequestIDTrust {
+		fields["InboundRequestID"], id = id, makeRequestID()
+	}
+	fields["RequestID"] = id
+	flow.Expose(xbconst.FlowKeyRequestID, id)
+	if _, ok := xbtrace.Extract(flow.GetRequest().Header); !ok {
+		flow.Expose(xbconst.FlowKeyFlowID, id)
+	}
+	flow.setLogger(fields)
+	flow.context.Request = flow.context.Request.WithContext(flow.WithRequestID(flow.context.Request.Context()))
+	flow.SetHeader(header, id)
+	return
+}
+
+// GetRequestID returns the ID set by `RequestIDMiddleware`, or an empty string
+// without the middleware.
+func (flow *RESTFlow) GetRequestID() string {
+	if flow.Contain(xbconst.FlowKeyRequestID) {
+		return flow.RequireString(xbconst.FlowKeyRequestID)
+	}
+	return ""
+}
+
+// WithRequestID carries the request ID of the flow along a context, which is
+// forwarded by `RequestIDTransport` on the outbound requests.
+func (flow *RESTFlow) WithRequestID(ctx context.Context) context.Context {
+	if id := flow.GetRequestID(); id != "" {
+		return context.WithValue(ctx, requestIDContextKey{}, id)
+	}
+	return ctx
+}
+
+func LookupRequestID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDContextKey{}).(string)
+	return id, ok
+}
+
+// RequestIDTransport forwards the request ID of the request contexts, see
+// `RESTFlow.WithRequestID`, unless the outbound requests have their own, e.g.
+// `&http.Client{Transport: &xbgin.RequestIDTransport{}}`.
+type RequestIDTransport struct {
+	Base http.RoundTripper
+}
+
+func (transport *RequestIDTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	header := xbcfg.GetHTTPConfig().RequestIDHeader
+	if id, ok := LookupRequestID(request.Context()); ok && request.Header.Get(header) == "" {
+		request = request.Clone(request.Context())
+		request.Header.Set(header, id)
+	}
+	return transport.getBase().RoundTrip(request)
+}
+
+func (transport *RequestIDTransport) getBase() http.RoundTripper {
+	if transport.Base != nil {
+		return transport.Base
+	}
+	return http.DefaultTransport
+}
+
+func makeRequestID() string {
+	if xbcfg.GetHTTPConfig().RequestIDFormat == RequestIDFormatXID {
+		return xbrand.MakeXID()
+	}
+	return xbrand.MakeKSUID()
+}
+
+// isValidRequestID accepts the printable ASCII IDs of bounded length, so that
+// the inbound ones can't forge the logs or the outbound headers.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := range len(id) {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
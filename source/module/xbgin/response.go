@@ -3,6 +3,7 @@ package xbgin
 import (
 	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbmtmsg"
 	"github.com/starryck/strk-tc-x-lib-go/source/core/model/xbdata"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xberror"
 )
 
 type (
@@ -12,6 +13,7 @@ type (
 	JSONResponsePageMeta = xbdata.JSONResponsePageMeta
 	JSONResponseBaseData = xbdata.JSONResponseBaseData
 	JSONResponsePageData = xbdata.JSONResponsePageData
+	JSONResponseError    = xbdata.JSONResponseError
 )
 
 func NewJSONResponse(message *MetaMessage, data any, options *JSONResponseOptions) *JSONResponse {
@@ -20,27 +22,36 @@ func NewJSONResponse(message *MetaMessage, data any, options *JSONResponseOption
 		setCode().
 		setMeta().
 		setData().
+		setErrors().
 		build()
 	return response
 }
 
 type JSONResponse struct {
-	Code int `json:"-"`
-	Meta any `json:"meta"`
-	Data any `json:"data"`
+	Code   int                  `json:"-"`
+	Locale string               `json:"-"`
+	Meta   any                  `json:"meta"`
+	Data   any                  `json:"data"`
+	Errors []*JSONResponseError `json:"errors,omitempty"`
 }
 
 type jsonResponseBuilder struct {
 	message  *MetaMessage
 	data     any
 	options  *JSONResponseOptions
+	locales  []string
 	response *JSONResponse
 }
 
+// JSONResponseOptions takes the negotiated locales of the meta messages, see
+// `xbmtmsg.NegotiateLocales`, which default to the ones of the default locale.
+// The details make the `errors` of the response, see `xberror.Options`.
 type JSONResponseOptions struct {
 	HTTPCode *int
 	MetaArgs []any
 	PageData *JSONResponsePageData
+	Details  []*xberror.Detail
+	Locales  []string
 }
 
 func (builder *jsonResponseBuilder) build() *JSONResponse {
@@ -79,6 +90,20 @@ func (builder *jsonResponseBuilder) setData() *jsonResponseBuilder {
 	return builder
 }
 
+func (builder *jsonResponseBuilder) setErrors() *jsonResponseBuilder {
+	for _, detail := range builder.options.Details {
+		message, _ := detail.Message.GetLocalOutText(builder.getLocales(), detail.OutArgs...)
+		builder.response.Errors = append(builder.response.Errors, &JSONResponseError{
+			Field:   detail.Field,
+			Rule:    detail.Rule,
+			Code:    detail.Message.GetOutCode(),
+			Message: message,
+			Value:   detail.Value,
+		})
+	}
+	return builder
+}
+
 func (builder *jsonResponseBuilder) makeMeta() *JSONResponseMeta {
 	meta := &JSONResponseMeta{
 		JSONResponseBaseData: JSONResponseBaseData{
@@ -106,6 +131,17 @@ func (builder *jsonResponseBuilder) makeMetaCode() string {
 }
 
 func (builder *jsonResponseBuilder) makeMetaMessage() string {
-	message := builder.message.GetOutText(builder.options.MetaArgs...)
+	message, locale := builder.message.GetLocalOutText(builder.getLocales(), builder.options.MetaArgs...)
+	builder.response.Locale = locale
 	return message
 }
+
+func (builder *jsonResponseBuilder) getLocales() []string {
+	if builder.locales == nil {
+		builder.locales = builder.options.Locales
+	}
+	if builder.locales == nil {
+		builder.locales = xbmtmsg.NegotiateLocales("")
+	}
+	return builder.locales
+}
@@ -1,17 +1,21 @@
 package xbgin
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"net/url"
 	"slices"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
 	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbconst"
 	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbmtmsg"
 	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xberror"
 	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xblogger"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbtrace"
 )
 
 type RESTFlow struct {
@@ -26,11 +30,61 @@ func (flow *RESTFlow) Initiate(context *Context) {
 		flow.SetStorage(storage)
 	} else {
 		flow.BaseFlow.Initiate()
+		flow.setTrace()
+		flow.setLevel()
+		flow.setLocales()
+		flow.setLogger(nil)
 		context.Set(xbconst.ContextFlowMap, flow.GetStorage())
 	}
 	return
 }
 
+// setTrace continues the trace of an inbound W3C trace context, whose trace ID
+// and parent span ID become the flow ID and the first trail. The server span
+// is ended by `RecordMiddleware`.
+func (flow *RESTFlow) setTrace() {
+	remote, ok := xbtrace.Extract(flow.context.Request.Header)
+	if ok {
+		flow.Expose(xbconst.FlowKeyFlowID, remote.GetTraceID())
+		flow.Expose(xbconst.FlowKeyFlowTrails, []string{remote.GetSpanID()})
+	}
+	span := xbtrace.StartSpan(flow.context.Request.Method, xbtrace.SpanKindServer, remote)
+	flow.SetSpan(span)
+	flow.context.Request = flow.context.Request.WithContext(xbtrace.IntoContext(flow.context.Request.Context(), span))
+	return
+}
+
+// setLevel applies the level signed by the debug header, see
+// `xblogger.MakeDebugToken`, to this request only.
+func (flow *RESTFlow) setLevel() {
+	token := flow.GetHeader(xbconst.HeaderLogDebug)
+	if token == "" {
+		return
+	}
+	if level, err := xblogger.ParseDebugToken(xbcfg.GetLogConfig().DebugSecret.Reveal(), token, time.Now()); err != nil {
+		flow.BaseFlow.GetLogger().WithError(err).Warn("Debug token is rejected.")
+	} else {
+		flow.SetLevel(level)
+	}
+	return
+}
+
+// setLocales negotiates the locales of the out texts, see
+// `xbmtmsg.NegotiateLocales`.
+func (flow *RESTFlow) setLocales() {
+	flow.Expose(xbconst.FlowKeyFlowLocales, xbmtmsg.NegotiateLocales(flow.GetHeader(xbconst.HeaderAcceptLanguage)))
+	return
+}
+
+// setLogger stores the flow logger in the request context, so that the code
+// receiving the context logs with the flow fields and the extra ones, e.g.
+// the gateway request ID.
+func (flow *RESTFlow) setLogger(fields xblogger.Fields) {
+	entry := flow.BaseFlow.GetLogger().WithFields(fields)
+	flow.context.Request = flow.context.Request.WithContext(xblogger.IntoContext(flow.context.Request.Context(), entry))
+	return
+}
+
 func (flow *RESTFlow) Inherit(fore Flow) {
 	panic("REST flow doesn't support inheritance.")
 }
@@ -47,6 +101,22 @@ func (flow *RESTFlow) SetNotFoundError() {
 	return
 }
 
+func (flow *RESTFlow) GetLogger() *xblogger.Entry {
+	if entry, ok := xblogger.LookupContext(flow.context.Request.Context()); ok {
+		return entry
+	}
+	return flow.BaseFlow.GetLogger()
+}
+
+func (flow *RESTFlow) WithLogger(ctx context.Context) context.Context {
+	return xblogger.IntoContext(ctx, flow.GetLogger())
+}
+
+func (flow *RESTFlow) GetLocales() []string {
+	locales := flow.RequireStrings(xbconst.FlowKeyFlowLocales)
+	return locales
+}
+
 func (flow *RESTFlow) GetContext() *Context {
 	context := flow.context
 	return context
@@ -129,11 +199,12 @@ func (flow *RESTFlow) GetParam(key string) string {
 func (flow *RESTFlow) BindParams(value any) {
 	if err := flow.context.ShouldBindUri(value); err != nil {
 		flow.SetError(xberror.Validation(xbmtmsg.WMV450, &xberror.Options{
+			OutDetails: MakeValidationDetails(err, value),
 			LogFields: xblogger.Fields{
 				"requestURI":   flow.GetRequestURI(),
 				"bindingValue": value,
 			},
-		}))
+		}, err))
 		return
 	}
 	flow.Expose(xbconst.FlowKeyRequestParams, value)
@@ -178,11 +249,12 @@ func (flow *RESTFlow) GetQueryFallback(key, fallback string) string {
 func (flow *RESTFlow) BindQueries(value any) {
 	if err := flow.context.ShouldBindQuery(value); err != nil {
 		flow.SetError(xberror.Validation(xbmtmsg.WMV451, &xberror.Options{
+			OutDetails: MakeValidationDetails(err, value),
 			LogFields: xblogger.Fields{
 				"requestQueries": flow.GetQueryValues(),
 				"bindingValue":   value,
 			},
-		}))
+		}, err))
 		return
 	}
 	flow.Expose(xbconst.FlowKeyRequestQueries, value)
@@ -212,11 +284,12 @@ func (flow *RESTFlow) GetHeaderValues() http.Header {
 func (flow *RESTFlow) BindHeaders(value any) {
 	if err := flow.context.ShouldBindHeader(value); err != nil {
 		flow.SetError(xberror.Validation(xbmtmsg.WMV452, &xberror.Options{
+			OutDetails: MakeValidationDetails(err, value),
 			LogFields: xblogger.Fields{
 				"requestHeaders": flow.GetHeaderValues(),
 				"bindingValue":   value,
 			},
-		}))
+		}, err))
 		return
 	}
 	flow.Expose(xbconst.FlowKeyRequestHeaders, value)
@@ -241,11 +314,12 @@ func (flow *RESTFlow) GetBody() io.ReadCloser {
 func (flow *RESTFlow) BindBody(value any) {
 	if err := flow.context.ShouldBind(value); err != nil {
 		flow.SetError(xberror.Validation(xbmtmsg.WMV453, &xberror.Options{
+			OutDetails: MakeValidationDetails(err, value),
 			LogFields: xblogger.Fields{
 				"requestBody":  string(flow.RequireData()),
 				"bindingValue": value,
 			},
-		}))
+		}, err))
 		return
 	}
 	flow.Expose(xbconst.FlowKeyRequestBody, value)
@@ -288,11 +362,29 @@ func (flow *RESTFlow) RespondFile(path string) {
 }
 
 func (flow *RESTFlow) RespondJSON(message *MetaMessage, data any, options *JSONResponseOptions) {
-	response := NewJSONResponse(message, data, options)
-	flow.context.JSON(response.Code, response)
+	if options == nil || options.Locales == nil {
+		localOptions := &JSONResponseOptions{}
+		if options != nil {
+			*localOptions = *options
+		}
+		localOptions.Locales = flow.GetLocales()
+		options = localOptions
+	}
+	flow.GetResponseFormat().Respond(flow, message, data, options)
 	return
 }
 
+// GetResponseFormat returns the format selected for the route, see
+// `NewResponseFormatMiddleware`, or the meta/data envelope by default.
+func (flow *RESTFlow) GetResponseFormat() ResponseFormat {
+	if value, ok := flow.context.Get(xbconst.ContextResponseFormat); ok {
+		if format, ok := value.(ResponseFormat); ok {
+			return format
+		}
+	}
+	return EnvelopeFormat
+}
+
 type KongFlow struct {
 	RESTFlow
 }
@@ -308,11 +400,19 @@ func (flow *KongFlow) setFields() {
 	fields["RequestID"] = flow.GetRequestID()
 	fields["ConsumerCustomID"] = flow.GetConsumerCustomID()
 	fields["ConsumerGroups"] = flow.GetConsumerGroups()
+	flow.setLogger(xblogger.Fields{
+		"RequestID":        fields["RequestID"],
+		"ConsumerCustomID": fields["ConsumerCustomID"],
+		"ConsumerGroups":   fields["ConsumerGroups"],
+	})
 	return
 }
 
 func (flow *KongFlow) GetRequestID() string {
 	id := flow.GetHeader(xbconst.HeaderKongRequestID)
+	if id == "" {
+		id = flow.RESTFlow.GetRequestID()
+	}
 	return id
 }
 
@@ -381,11 +481,19 @@ func (flow *APISIXFlow) setFields() {
 	fields["RequestID"] = flow.GetRequestID()
 	fields["ConsumerName"] = flow.GetConsumerName()
 	fields["ConsumerGroupID"] = flow.GetConsumerGroupID()
+	flow.setLogger(xblogger.Fields{
+		"RequestID":       fields["RequestID"],
+		"ConsumerName":    fields["ConsumerName"],
+		"ConsumerGroupID": fields["ConsumerGroupID"],
+	})
 	return
 }
 
 func (flow *APISIXFlow) GetRequestID() string {
 	id := flow.GetHeader(xbconst.HeaderAPISIXRequestID)
+	if id == "" {
+		id = flow.RESTFlow.GetRequestID()
+	}
 	return id
 }
 
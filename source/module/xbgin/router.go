@@ -10,13 +10,16 @@ func NewRouter() *Router {
 		initialize().
 		setEngine().
 		setCORSConfig().
+		setCheckers().
 		build()
 	return router
 }
 
 type Router struct {
-	engine     *Engine
-	corsConfig *CORSConfig
+	engine         *Engine
+	corsConfig     *CORSConfig
+	checkers       map[string]Checker
+	responseFormat ResponseFormat
 }
 
 type RouterStem struct {
@@ -40,6 +43,10 @@ func (router *Router) GetCORSConfig() *CORSConfig {
 	return router.corsConfig
 }
 
+// UseMiddlewares must be called before any route gets registered, e.g. by
+// `SetRouterGroup`, `UseHealth`, `UseMetrics` or `UseLogLevels`, since gin only
+// applies the middlewares to the routes registered after them. In turn,
+// `SetResponseFormat` must be called before it.
 func (router *Router) UseMiddlewares() {
 	router.SetMiddlewares(router.NewMiddlewares()...)
 }
@@ -49,13 +56,19 @@ func (router *Router) SetMiddlewares(handlers ...Handler) {
 }
 
 func (router *Router) NewMiddlewares() []Handler {
-	return []Handler{
-		gin.Recovery(),
+	handlers := []Handler{
+		RecoveryMiddleware,
+		RequestIDMiddleware,
+	}
+	if router.responseFormat != nil {
+		handlers = append(handlers, NewResponseFormatMiddleware(router.responseFormat))
+	}
+	return append(handlers,
 		cors.New(*router.corsConfig),
 		GraceMiddleware,
 		RecordMiddleware,
 		ResponseMiddleware,
-	}
+	)
 }
 
 func (router *Router) SetRouterGroup(stems ...RouterStem) {
@@ -100,3 +113,8 @@ func (builder *routerBuilder) setCORSConfig() *routerBuilder {
 	builder.router.corsConfig = &corsConfig
 	return builder
 }
+
+func (builder *routerBuilder) setCheckers() *routerBuilder {
+	builder.router.checkers = defaultCheckers()
+	return builder
+}
@@ -0,0 +1,214 @@
+package xbgin
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbmtmsg"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xberror"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xblogger"
+)
+
+const typeValidationRule = "type"
+
+var fieldTagKeys = []string{"json", "form", "uri", "header"}
+
+var validationMessageMutex sync.RWMutex
+
+var validationMessageMap = map[string]*MetaMessage{
+	"required":         xbmtmsg.WMV460,
+	"required_if":      xbmtmsg.WMV460,
+	"required_unless":  xbmtmsg.WMV460,
+	"required_with":    xbmtmsg.WMV460,
+	"required_without": xbmtmsg.WMV460,
+	"min":              xbmtmsg.WMV461,
+	"gte":              xbmtmsg.WMV461,
+	"max":              xbmtmsg.WMV462,
+	"lte":              xbmtmsg.WMV462,
+	"gt":               xbmtmsg.WMV463,
+	"lt":               xbmtmsg.WMV464,
+	"len":              xbmtmsg.WMV465,
+	"oneof":            xbmtmsg.WMV466,
+	"email":            xbmtmsg.WMV467,
+	"url":              xbmtmsg.WMV467,
+	"uri":              xbmtmsg.WMV467,
+	"uuid":             xbmtmsg.WMV467,
+	"uuid4":            xbmtmsg.WMV467,
+	"datetime":         xbmtmsg.WMV467,
+	"e164":             xbmtmsg.WMV467,
+	"ip":               xbmtmsg.WMV467,
+	"ipv4":             xbmtmsg.WMV467,
+	"ipv6":             xbmtmsg.WMV467,
+	"hostname":         xbmtmsg.WMV467,
+	"alpha":            xbmtmsg.WMV467,
+	"alphanum":         xbmtmsg.WMV467,
+	"numeric":          xbmtmsg.WMV467,
+	"number":           xbmtmsg.WMV467,
+	"hexadecimal":      xbmtmsg.WMV467,
+	"json":             xbmtmsg.WMV467,
+	"jwt":              xbmtmsg.WMV467,
+	"base64":           xbmtmsg.WMV467,
+	typeValidationRule: xbmtmsg.WMV468,
+}
+
+// RegisterValidationMessage sets the message of the details of a validator rule,
+// replacing the default one if any. The out text may take the placeholders
+// `{field}`, `{rule}`, `{param}` and `{value}`, where the value is left empty
+// for the sensitive fields and the param is converted to the type of its
+// placeholder, e.g. `{param:int}`.
+func RegisterValidationMessage(rule string, message *MetaMessage) {
+	validationMessageMutex.Lock()
+	defer validationMessageMutex.Unlock()
+	validationMessageMap[rule] = message
+}
+
+func getValidationMessage(rule string) *MetaMessage {
+	validationMessageMutex.RLock()
+	defer validationMessageMutex.RUnlock()
+	if message, ok := validationMessageMap[rule]; ok {
+		return message
+	}
+	return xbmtmsg.WMV469
+}
+
+// MakeValidationDetails turns the validator and JSON type errors of binding the
+// value into the details of the response `errors`, or returns nil for the other
+// errors. The fields are named after their tags in the value type, so that the
+// details locate them as the clients send them.
+func MakeValidationDetails(err error, value any) []*xberror.Detail {
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		details := make([]*xberror.Detail, len(verrs))
+		for i, verr := range verrs {
+			field := getFieldPath(reflect.TypeOf(value), verr.StructNamespace())
+			details[i] = makeValidationDetail(field, verr.Tag(), verr.Param(), verr.Value())
+		}
+		return details
+	}
+	var terr *json.UnmarshalTypeError
+	if errors.As(err, &terr) && terr.Field != "" {
+		return []*xberror.Detail{makeValidationDetail(terr.Field, typeValidationRule, terr.Type.Kind().String(), nil)}
+	}
+	return nil
+}
+
+func makeValidationDetail(field, rule, param string, value any) *xberror.Detail {
+	message := getValidationMessage(rule)
+	value = makeSafeValue(field, rule, value)
+	values := xbmtmsg.Args{"field": field, "rule": rule, "param": param, "value": value}
+	args := xbmtmsg.Args{}
+	for name, kind := range message.GetOutPlaceholders() {
+		args[name] = values[name]
+		if name == "param" {
+			args[name] = convertParam(kind, param)
+		}
+	}
+	return &xberror.Detail{Field: field, Rule: rule, Message: message, OutArgs: []any{args}, Value: value}
+}
+
+// makeSafeValue keeps the rejected scalar values, except the empty ones of the
+// required rules and the ones of the fields redacted by the logger.
+func makeSafeValue(field, rule string, value any) any {
+	if strings.HasPrefix(rule, "required") || value == nil {
+		return nil
+	}
+	name := field[strings.LastIndex(field, ".")+1:]
+	name, _, _ = strings.Cut(name, "[")
+	if xblogger.IsSensitiveField(name) {
+		return nil
+	}
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return value
+	}
+	return nil
+}
+
+// convertParam keeps the rule param as is unless the placeholder is typed and
+// the param parses as the type, e.g. the `3` of `min=3` for `{param:int}`.
+func convertParam(kind, param string) any {
+	switch kind {
+	case xbmtmsg.PlaceholderInt:
+		if value, err := strconv.Atoi(param); err == nil {
+			return value
+		}
+	case xbmtmsg.PlaceholderFloat:
+		if value, err := strconv.ParseFloat(param, 64); err == nil {
+			return value
+		}
+	case xbmtmsg.PlaceholderBool:
+		if value, err := strconv.ParseBool(param); err == nil {
+			return value
+		}
+	}
+	return param
+}
+
+// getFieldPath drops the struct name of a namespace and renames its fields
+// after their tags in the root type, e.g. `items[0].name` of
+// `Body.Items[0].Name`. The fields which aren't found keep their names.
+func getFieldPath(root reflect.Type, namespace string) string {
+	_, path, ok := strings.Cut(namespace, ".")
+	if !ok {
+		return namespace
+	}
+	segments := strings.Split(path, ".")
+	current := root
+	for i, segment := range segments {
+		name, index, _ := strings.Cut(segment, "[")
+		current = derefType(current)
+		if current == nil || current.Kind() != reflect.Struct {
+			current = nil
+			continue
+		}
+		field, ok := current.FieldByName(name)
+		if !ok {
+			current = nil
+			continue
+		}
+		if tagName := getFieldTagName(field); tagName != "" {
+			segments[i] = tagName
+			if index != "" {
+				segments[i] += "[" + index
+			}
+		}
+		current = field.Type
+		for range strings.Count(segment, "[") {
+			if current = derefType(current); current != nil {
+				switch current.Kind() {
+				case reflect.Slice, reflect.Array, reflect.Map:
+					current = current.Elem()
+				default:
+					current = nil
+				}
+			}
+		}
+	}
+	return strings.Join(segments, ".")
+}
+
+func derefType(current reflect.Type) reflect.Type {
+	for current != nil && current.Kind() == reflect.Pointer {
+		current = current.Elem()
+	}
+	return current
+}
+
+func getFieldTagName(field reflect.StructField) string {
+	for _, key := range fieldTagKeys {
+		name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package xbgorm
+
+import (
+	"errors"
+	"time"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbmetric"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbtrace"
+)
+
+const (
+	callbackStartTimeKey = "xbgorm:start_time"
+	callbackSpanKey      = "xbgorm:span"
+)
+
+var queryDurationHistogram = xbmetric.NewHistogram("gorm_query_duration_seconds",
+	"Duration of gorm operations in seconds.", xbmetric.DurationBuckets, "operation", "table", "status")
+
+// registerCallbacks times every gorm operation, from before its first built-in
+// callback to after its last one. A span is recorded as well when the
+// statement context carries one, e.g. `client.WithContext(flow.WithSpan(ctx))`.
+func registerCallbacks(client *Client) error {
+	callbacks := client.Callback()
+	return errors.Join(
+		callbacks.Create().Before("*").Register("xbgorm:before_create", makeStartCallback("create")),
+		callbacks.Create().After("*").Register("xbgorm:after_create", makeEndCallback("create")),
+		callbacks.Query().Before("*").Register("xbgorm:before_query", makeStartCallback("query")),
+		callbacks.Query().After("*").Register("xbgorm:after_query", makeEndCallback("query")),
+		callbacks.Update().Before("*").Register("xbgorm:before_update", makeStartCallback("update")),
+		callbacks.Update().After("*").Register("xbgorm:after_update", makeEndCallback("update")),
+		callbacks.Delete().Before("*").Register("xbgorm:before_delete", makeStartCallback("delete")),
+		callbacks.Delete().After("*").Register("xbgorm:after_delete", makeEndCallback("delete")),
+		callbacks.Row().Before("*").Register("xbgorm:before_row", makeStartCallback("row")),
+		callbacks.Row().After("*").Register("xbgorm:after_row", makeEndCallback("row")),
+		callbacks.Raw().Before("*").Register("xbgorm:before_raw", makeStartCallback("raw")),
+		callbacks.Raw().After("*").Register("xbgorm:after_raw", makeEndCallback("raw")),
+	)
+}
+
+func makeStartCallback(operation string) func(db *Client) {
+	return func(db *Client) {
+		db.InstanceSet(callbackStartTimeKey, time.Now())
+		if parent, ok := xbtrace.FromContext(db.Statement.Context); ok {
+			span := xbtrace.StartSpan("gorm."+operation, xbtrace.SpanKindClient, parent.GetContext())
+			span.SetAttribute("db.system", "postgresql")
+			span.SetAttribute("db.operation", operation)
+			db.InstanceSet(callbackSpanKey, span)
+		}
+	}
+}
+
+func makeEndCallback(operation string) func(db *Client) {
+	return func(db *Client) {
+		status := "ok"
+		if db.Error != nil && !IsErrRecordNotFound(db.Error) {
+			status = "error"
+		}
+		if value, ok := db.InstanceGet(callbackSpanKey); ok {
+			span := value.(*xbtrace.Span)
+			span.SetAttribute("db.sql.table", db.Statement.Table)
+			span.SetAttribute("db.statement", db.Statement.SQL.String())
+			span.SetAttribute("db.rows_affected", db.RowsAffected)
+			if status == "error" {
+				span.SetError(db.Error)
+			}
+			span.End()
+		}
+		if value, ok := db.InstanceGet(callbackStartTimeKey); ok {
+			queryDurationHistogram.Observe(time.Since(value.(time.Time)).Seconds(), operation, db.Statement.Table, status)
+		}
+	}
+}
@@ -1,21 +1,30 @@
 package xbgorm
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 	"gorm.io/gorm/schema"
 
 	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbcfg"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/base/xbconst"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xberror"
 	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xblogger"
 )
 
 var mPostgresClient *PostgresClient
 
+func init() {
+	xbcfg.UseModule(xbconst.ModulePostgres)
+	xberror.RegisterClassifier(classifyPostgresError)
+}
+
 type PostgresClient = Client
 
 func GetPostgresClient() *PostgresClient {
@@ -36,6 +45,7 @@ func NewPostgresClient(options *PostgresClientOptions) *PostgresClient {
 		setPassword().
 		setClient().
 		initClient().
+		setCallbacks().
 		build()
 	return client
 }
@@ -154,6 +164,13 @@ func (builder *postgresClientBuilder) initClient() *postgresClientBuilder {
 	return builder
 }
 
+func (builder *postgresClientBuilder) setCallbacks() *postgresClientBuilder {
+	if err := registerCallbacks(builder.client); err != nil {
+		panic(err)
+	}
+	return builder
+}
+
 type postgresClientConfigs struct {
 	dsn      string
 	host     string
@@ -165,10 +182,7 @@ type postgresClientConfigs struct {
 
 func (configs *postgresClientConfigs) getConfig() *Config {
 	config := &Config{
-		Logger: logger.New(xblogger.GetLogger(), logger.Config{
-			LogLevel:      logger.Warn,
-			SlowThreshold: 200 * time.Millisecond,
-		}),
+		Logger: newContextLogger(),
 		NamingStrategy: &schema.NamingStrategy{
 			SingularTable: true,
 		},
@@ -177,15 +191,50 @@ func (configs *postgresClientConfigs) getConfig() *Config {
 }
 
 func (configs *postgresClientConfigs) getDialector() Dialector {
-	dialector := postgres.Open(configs.makeDSN())
+	dialector := postgres.New(postgres.Config{Conn: configs.makeConn()})
 	return dialector
 }
 
+// The password is set on the parsed connection config rather than embedded in
+// the DSN, so that it can never leak through connection errors.
+func (configs *postgresClientConfigs) makeConn() *sql.DB {
+	config, err := pgx.ParseConfig(configs.makeDSN())
+	if err != nil {
+		panic(err)
+	}
+	if configs.dsn == "" {
+		config.Password = configs.password
+	}
+	conn := stdlib.OpenDB(*config)
+	return conn
+}
+
 func (configs *postgresClientConfigs) makeDSN() string {
 	dsn := configs.dsn
 	if dsn == "" {
-		dsn = fmt.Sprintf("host=%s port=%s dbname=%s user=%s password=%s sslmode=disable",
-			configs.host, configs.port, configs.name, configs.user, configs.password)
+		dsn = fmt.Sprintf("host=%s port=%s dbname=%s user=%s sslmode=disable",
+			configs.host, configs.port, configs.name, configs.user)
 	}
 	return dsn
 }
+
+// PreparePostgresClient creates the shared client ahead of its first use and
+// checks the connection, reporting failures as an error instead of a panic.
+func PreparePostgresClient() error {
+	return CheckPostgresClient(context.Background())
+}
+
+// CheckPostgresClient pings the shared client, creating it when needed, and
+// is meant to be used as a readiness checker.
+func CheckPostgresClient(ctx context.Context) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("Postgres client failed to be created: %v", v)
+		}
+	}()
+	db, err := GetPostgresClient().DB()
+	if err != nil {
+		return err
+	}
+	return db.PingContext(ctx)
+}
@@ -0,0 +1,81 @@
+package xbgorm
+
+import (
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xberror"
+)
+
+// Postgres SQLSTATE codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html.
+const (
+	SQLStateForeignKeyViolation   = "23503"
+	SQLStateUniqueViolation       = "23505"
+	SQLStateSerializationFailure  = "40001"
+	SQLStateDeadlockDetected      = "40P01"
+	SQLStateInsufficientPrivilege = "42501"
+	SQLStateLockNotAvailable      = "55P03"
+	SQLStateQueryCanceled         = "57014"
+)
+
+var (
+	ErrDuplicatedKey      = gorm.ErrDuplicatedKey
+	ErrForeignKeyViolated = gorm.ErrForeignKeyViolated
+)
+
+// The insufficient privilege of the database role is left unclassified, since
+// it is a fault of the service rather than a permission of the client.
+var sqlStateCategoryMap = map[string]*xberror.Category{
+	SQLStateForeignKeyViolation:  xberror.CategoryConflict,
+	SQLStateUniqueViolation:      xberror.CategoryConflict,
+	SQLStateSerializationFailure: xberror.CategoryRetryable,
+	SQLStateDeadlockDetected:     xberror.CategoryRetryable,
+	SQLStateLockNotAvailable:     xberror.CategoryTimeout,
+	SQLStateQueryCanceled:        xberror.CategoryTimeout,
+}
+
+func GetSQLState(err error) (string, bool) {
+	var pgErr *pgconn.PgError
+	if !xberror.As(err, &pgErr) {
+		return "", false
+	}
+	return pgErr.Code, true
+}
+
+func IsErrUniqueViolation(err error) bool {
+	return xberror.Is(err, ErrDuplicatedKey) || hasSQLState(err, SQLStateUniqueViolation)
+}
+
+func IsErrForeignKeyViolation(err error) bool {
+	return xberror.Is(err, ErrForeignKeyViolated) || hasSQLState(err, SQLStateForeignKeyViolation)
+}
+
+func IsErrSerializationFailure(err error) bool {
+	return hasSQLState(err, SQLStateSerializationFailure)
+}
+
+func IsErrDeadlockDetected(err error) bool {
+	return hasSQLState(err, SQLStateDeadlockDetected)
+}
+
+func IsErrLockNotAvailable(err error) bool {
+	return hasSQLState(err, SQLStateLockNotAvailable)
+}
+
+func hasSQLState(err error, code string) bool {
+	state, ok := GetSQLState(err)
+	return ok && state == code
+}
+
+// classifyPostgresError recognizes the SQLSTATE codes of the Postgres errors and
+// the ones translated by gorm when `TranslateError` is enabled.
+func classifyPostgresError(err error) *xberror.Category {
+	switch err {
+	case ErrDuplicatedKey, ErrForeignKeyViolated:
+		return xberror.CategoryConflict
+	}
+	if pgErr, ok := err.(*pgconn.PgError); ok {
+		return sqlStateCategoryMap[pgErr.Code]
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package xbgorm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xblogger"
+)
+
+const defaultSlowThreshold = 200 * time.Millisecond
+
+// contextLogger writes the gorm logs through the entry carried by the
+// statement context, e.g. `client.WithContext(flow.WithLogger(ctx))`, so that
+// they are correlated with the flow. Every statement is logged at debug level
+// once the client is in debug mode.
+type contextLogger struct {
+	level         logger.LogLevel
+	slowThreshold time.Duration
+}
+
+func newContextLogger() *contextLogger {
+	return &contextLogger{level: logger.Warn, slowThreshold: defaultSlowThreshold}
+}
+
+func (clogger *contextLogger) LogMode(level logger.LogLevel) logger.Interface {
+	nlogger := *clogger
+	nlogger.level = level
+	return &nlogger
+}
+
+func (clogger *contextLogger) Info(ctx context.Context, format string, args ...any) {
+	if clogger.level >= logger.Info {
+		clogger.getEntry(ctx).Infof(format, args...)
+	}
+}
+
+func (clogger *contextLogger) Warn(ctx context.Context, format string, args ...any) {
+	if clogger.level >= logger.Warn {
+		clogger.getEntry(ctx).Warnf(format, args...)
+	}
+}
+
+func (clogger *contextLogger) Error(ctx context.Context, format string, args ...any) {
+	if clogger.level >= logger.Error {
+		clogger.getEntry(ctx).Errorf(format, args...)
+	}
+}
+
+func (clogger *contextLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if clogger.level <= logger.Silent {
+		return
+	}
+	duration := time.Since(begin)
+	switch {
+	case err != nil && clogger.level >= logger.Error && !IsErrRecordNotFound(err):
+		clogger.getTraceEntry(ctx, duration, fc).WithError(err).Error("Gorm failed to execute statement.")
+	case clogger.slowThreshold > 0 && duration > clogger.slowThreshold && clogger.level >= logger.Warn:
+		clogger.getTraceEntry(ctx, duration, fc).Warnf("Gorm executed slow statement over %v.", clogger.slowThreshold)
+	case clogger.level >= logger.Info:
+		clogger.getTraceEntry(ctx, duration, fc).Debug("Gorm executed statement.")
+	}
+}
+
+func (clogger *contextLogger) getEntry(ctx context.Context) *xblogger.Entry {
+	return xblogger.FromContext(ctx).WithField("source", utils.FileWithLineNum())
+}
+
+func (clogger *contextLogger) getTraceEntry(ctx context.Context, duration time.Duration, fc func() (string, int64)) *xblogger.Entry {
+	sql, rows := fc()
+	fields := xblogger.Fields{
+		"sql":      sql,
+		"duration": fmt.Sprintf("%.3fms", float64(duration.Nanoseconds())/1e6),
+	}
+	if rows >= 0 {
+		fields["rowsAffected"] = rows
+	}
+	return clogger.getEntry(ctx).WithFields(fields)
+}
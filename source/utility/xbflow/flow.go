@@ -1,6 +1,7 @@
 package xbflow
 
 import (
+	"context"
 	"fmt"
 	"runtime/debug"
 	"strings"
@@ -12,6 +13,7 @@ import (
 	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbctnr"
 	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbjson"
 	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xblogger"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbtrace"
 )
 
 type Flow interface {
@@ -19,6 +21,11 @@ type Flow interface {
 	GetTrails() []string
 }
 
+const (
+	spanNameFlow  = "flow"
+	spanNameAsync = "flow.async"
+)
+
 type BaseFlow struct {
 	storage *sync.Map
 }
@@ -40,6 +47,16 @@ func (flow *BaseFlow) Inherit(fore Flow) {
 	flow.storage.Store(xbconst.FlowKeyFlowTrails, append(fore.GetTrails(), xbrand.MakeBase62String(8)))
 	flow.storage.Store(xbconst.FlowKeyFlowError, nil)
 	flow.storage.Store(xbconst.FlowKeyFlowOutcome, nil)
+	if fore, ok := fore.(interface{ GetSpanContext() *xbtrace.SpanContext }); ok {
+		if parent := fore.GetSpanContext(); parent != nil {
+			flow.storage.Store(xbconst.FlowKeyFlowParent, parent)
+		}
+	}
+	if fore, ok := fore.(interface{ GetStorage() *sync.Map }); ok {
+		if level, ok := fore.GetStorage().Load(xbconst.FlowKeyFlowLevel); ok {
+			flow.storage.Store(xbconst.FlowKeyFlowLevel, level)
+		}
+	}
 	return
 }
 
@@ -53,6 +70,66 @@ func (flow *BaseFlow) GetTrails() []string {
 	return trails.([]string)
 }
 
+// GetSpan starts the flow span on the first use, as a child of the span of the
+// inherited flow if any, unless a span has been set, e.g. the server span of
+// `xbgin.RESTFlow`. The caller starting the span is expected to end it.
+func (flow *BaseFlow) GetSpan() *xbtrace.Span {
+	if span, ok := flow.LookupSpan(); ok {
+		return span
+	}
+	span, _ := flow.storage.LoadOrStore(xbconst.FlowKeyFlowSpan,
+		xbtrace.StartSpan(spanNameFlow, xbtrace.SpanKindInternal, flow.GetSpanContext()))
+	return span.(*xbtrace.Span)
+}
+
+// LookupSpan returns the flow span only when it has been started or set.
+func (flow *BaseFlow) LookupSpan() (*xbtrace.Span, bool) {
+	span, ok := flow.storage.Load(xbconst.FlowKeyFlowSpan)
+	if !ok {
+		return nil, false
+	}
+	return span.(*xbtrace.Span), true
+}
+
+// GetSpanContext returns the context of the flow span, or of the span of the
+// inherited flow before the flow span is started, without starting it.
+func (flow *BaseFlow) GetSpanContext() *xbtrace.SpanContext {
+	if span, ok := flow.LookupSpan(); ok {
+		return span.GetContext()
+	}
+	parent, _ := flow.storage.Load(xbconst.FlowKeyFlowParent)
+	spanContext, _ := parent.(*xbtrace.SpanContext)
+	return spanContext
+}
+
+func (flow *BaseFlow) SetSpan(span *xbtrace.Span) {
+	flow.storage.Store(xbconst.FlowKeyFlowSpan, span)
+	return
+}
+
+// WithSpan carries the flow span in a context, e.g. to trace the queries of
+// `client.WithContext(flow.WithSpan(ctx))` as its children. The context stays
+// as is when the flow span hasn't been started.
+func (flow *BaseFlow) WithSpan(ctx context.Context) context.Context {
+	if span, ok := flow.LookupSpan(); ok {
+		return xbtrace.IntoContext(ctx, span)
+	}
+	return ctx
+}
+
+// WithLogger carries the flow logger in a context, so that the code below the
+// flow, e.g. `xblogger.FromContext(ctx)`, logs with the flow fields.
+func (flow *BaseFlow) WithLogger(ctx context.Context) context.Context {
+	return xblogger.IntoContext(ctx, flow.GetLogger())
+}
+
+// SetLevel lowers the log threshold of this flow only, e.g. to debug a single
+// request without changing the service level.
+func (flow *BaseFlow) SetLevel(level xblogger.Level) {
+	flow.storage.Store(xbconst.FlowKeyFlowLevel, level)
+	return
+}
+
 func (flow *BaseFlow) HasError() bool {
 	err, _ := flow.storage.Load(xbconst.FlowKeyFlowError)
 	return err != nil
@@ -92,11 +169,18 @@ func (flow *BaseFlow) SetStorage(storage *sync.Map) {
 }
 
 func (flow *BaseFlow) GetLogger() *xblogger.Entry {
-	entry := xblogger.WithFields(xblogger.Fields{
+	fields := xblogger.Fields{
 		"flowID":     flow.GetID(),
 		"flowTrails": fmt.Sprintf("/%s", strings.Join(flow.GetTrails(), "/")),
-	})
-	return entry
+	}
+	if spanContext := flow.GetSpanContext(); spanContext != nil {
+		fields[xblogger.TraceIDKey] = spanContext.GetTraceID()
+		fields[xblogger.SpanIDKey] = spanContext.GetSpanID()
+	}
+	if level, ok := flow.storage.Load(xbconst.FlowKeyFlowLevel); ok {
+		return xblogger.WithLevel(level.(xblogger.Level)).WithFields(fields)
+	}
+	return xblogger.WithFields(fields)
 }
 
 func (flow *BaseFlow) Contain(key string) bool {
@@ -334,13 +418,17 @@ func (flow *BaseFlow) RequireRawMessage(key string) xbjson.RawMessage {
 }
 
 func (flow *BaseFlow) Async(operate Operate, args ...any) {
+	span := xbtrace.StartSpan(spanNameAsync, xbtrace.SpanKindInternal, flow.GetSpanContext())
 	go func() {
+		defer span.End()
 		defer func() {
 			if v := recover(); v != nil {
+				span.SetError(fmt.Errorf("Flow async operation panicked: %v", v))
 				flow.GetLogger().WithField(xblogger.PanicKey, xblogger.FormatPanic(v, debug.Stack())).Error("Flow async operation panicked.")
 			}
 		}()
 		if err := operate(args...); err != nil {
+			span.SetError(err)
 			flow.GetLogger().WithError(err).Error("Flow async operation failed.")
 		}
 	}()
@@ -12,10 +12,24 @@ import (
 	"time"
 
 	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xblogger"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbmetric"
+	"github.com/starryck/strk-tc-x-lib-go/source/core/utility/xbtrace"
 )
 
 var mSupervisor *Supervisor
 
+var (
+	mReloads      []Reload
+	mReloadsMutex sync.Mutex
+)
+
+var (
+	daemonGauge = xbmetric.NewGauge("supervisor_daemons_active",
+		"Number of running daemons by process type.", "daemon")
+	reloadCounter = xbmetric.NewCounter("supervisor_reloads_total",
+		"Total number of supervisor reloads by status.", "status")
+)
+
 func GetSupervisor(options *SupervisorOptions) *Supervisor {
 	if mSupervisor == nil {
 		mSupervisor = newSupervisor(options)
@@ -32,10 +46,23 @@ func newSupervisor(options *SupervisorOptions) *Supervisor {
 		setSignalChannel().
 		setGracefulTimeout().
 		setHeartbeatInterval().
+		setReloads().
 		build()
 	return supervisor
 }
 
+// HandleReload registers an operation performed on SIGHUP by the supervisor,
+// even before it gets created, e.g. the config reload registered by
+// `xbpreset`.
+func HandleReload(reload Reload) {
+	mReloadsMutex.Lock()
+	mReloads = append(mReloads, reload)
+	mReloadsMutex.Unlock()
+	if mSupervisor != nil {
+		mSupervisor.HandleReload(reload)
+	}
+}
+
 func GetWaitGroup() *sync.WaitGroup {
 	if mSupervisor == nil {
 		panic("Supervisor hasn't been created.")
@@ -50,6 +77,19 @@ func GetRootContext() context.Context {
 	return mSupervisor.rootContext
 }
 
+// IsShuttingDown reports whether the supervisor has started to shut down, so
+// that new work can be turned away while the running one is drained.
+func IsShuttingDown() bool {
+	return mSupervisor != nil && mSupervisor.rootContext.Err() != nil
+}
+
+func CheckSupervisor(ctx context.Context) error {
+	if IsShuttingDown() {
+		return fmt.Errorf("Supervisor is shutting down.")
+	}
+	return nil
+}
+
 func WithWaitGroup(operate Operate, args ...any) {
 	wg := GetWaitGroup()
 	wg.Add(1)
@@ -64,10 +104,15 @@ const (
 	exitCodeFailure
 )
 
-type Operate = func(args ...any)
+type (
+	Operate = func(args ...any)
+	Reload  = func() error
+)
 
 type Supervisor struct {
 	daemons       []*Daemon
+	reloads       []Reload
+	reloadsMutex  sync.Mutex
 	exitCode      int
 	waitGroup     *sync.WaitGroup
 	waitChannel   chan struct{}
@@ -87,6 +132,22 @@ func (supervisor *Supervisor) Handle(process Process) {
 	})
 }
 
+// HandleReload registers an operation performed on SIGHUP. Without any of
+// them, SIGHUP gracefully shuts the supervisor down like SIGINT and SIGTERM,
+// though `xbpreset` registers the config reload by default.
+func (supervisor *Supervisor) HandleReload(reload Reload) {
+	supervisor.reloadsMutex.Lock()
+	defer supervisor.reloadsMutex.Unlock()
+	supervisor.reloads = append(supervisor.reloads, reload)
+}
+
+// getReloads copies the reloads, which may be registered while serving.
+func (supervisor *Supervisor) getReloads() []Reload {
+	supervisor.reloadsMutex.Lock()
+	defer supervisor.reloadsMutex.Unlock()
+	return append([]Reload{}, supervisor.reloads...)
+}
+
 func (supervisor *Supervisor) RunForever() {
 	supervisor.setupDaemons()
 	supervisor.startDaemons()
@@ -117,15 +178,34 @@ func (supervisor *Supervisor) serveDaemons() {
 		select {
 		case <-tick:
 			supervisor.emitBeatInfo()
-		case <-supervisor.signalChannel:
-			supervisor.rootCanceller()
+		case sig := <-supervisor.signalChannel:
+			if reloads := supervisor.getReloads(); sig == syscall.SIGHUP && len(reloads) > 0 {
+				supervisor.reloadDaemons(reloads)
+			} else {
+				supervisor.rootCanceller()
+			}
 		case <-supervisor.rootContext.Done():
 			supervisor.emitShutInfo()
 			supervisor.waitDaemons()
 			supervisor.emitExitInfo()
+			supervisor.flushTracer()
+			supervisor.flushLogger()
+			return
+		}
+	}
+}
+
+func (supervisor *Supervisor) reloadDaemons(reloads []Reload) {
+	fields := supervisor.makeLoggerFields()
+	for _, reload := range reloads {
+		if err := reload(); err != nil {
+			reloadCounter.Inc("failure")
+			xblogger.WithFields(fields).WithError(err).Error("Supervisor failed to reload.")
 			return
 		}
 	}
+	reloadCounter.Inc("success")
+	xblogger.WithFields(fields).Info("Supervisor reloaded.")
 }
 
 func (supervisor *Supervisor) emitBeatInfo() {
@@ -168,6 +248,21 @@ func (supervisor *Supervisor) emitExitInfo() {
 	}
 }
 
+// flushTracer exports the spans still queued by the batch exporter.
+func (supervisor *Supervisor) flushTracer() {
+	if err := xbtrace.FlushWithTimeout(defaultFlushTimeout); err != nil {
+		fmt.Fprintf(os.Stderr, "Supervisor failed to flush tracer: %v\n", err)
+	}
+}
+
+// flushLogger writes the entries still queued by the async log outputs, which
+// would otherwise be lost once the process exits.
+func (supervisor *Supervisor) flushLogger() {
+	if err := xblogger.FlushWithTimeout(defaultFlushTimeout); err != nil {
+		fmt.Fprintf(os.Stderr, "Supervisor failed to flush logger: %v\n", err)
+	}
+}
+
 func (supervisor *Supervisor) makeLoggerFields() xblogger.Fields {
 	actives := []string{}
 	inactives := []string{}
@@ -187,6 +282,7 @@ func (supervisor *Supervisor) makeLoggerFields() xblogger.Fields {
 const (
 	defaultGracefulTimeout   = 30 * time.Second
 	defaultHeartbeatInterval = 5 * time.Minute
+	defaultFlushTimeout      = 5 * time.Second
 )
 
 type supervisorBuilder struct {
@@ -231,7 +327,7 @@ func (builder *supervisorBuilder) setRootContext() *supervisorBuilder {
 
 func (builder *supervisorBuilder) setSignalChannel() *supervisorBuilder {
 	sigchn := make(chan os.Signal, 1)
-	signal.Notify(sigchn, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigchn, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 	builder.supervisor.signalChannel = sigchn
 	return builder
 }
@@ -256,6 +352,13 @@ func (builder *supervisorBuilder) setHeartbeatInterval() *supervisorBuilder {
 	return builder
 }
 
+func (builder *supervisorBuilder) setReloads() *supervisorBuilder {
+	mReloadsMutex.Lock()
+	defer mReloadsMutex.Unlock()
+	builder.supervisor.reloads = append([]Reload{}, mReloads...)
+	return builder
+}
+
 type Daemon struct {
 	process  Process
 	typeName string
@@ -269,15 +372,17 @@ func (daemon *Daemon) setup() {
 }
 
 func (daemon *Daemon) start(ctx context.Context) {
-	fields := xblogger.Fields{"daemon": daemon.String()}
-	if err := daemon.process.Start(ctx); err == nil {
-		xblogger.WithFields(fields).Info("Daemon succeeded in exiting process.")
+	daemonGauge.Inc(daemon.typeName)
+	logger := xblogger.WithFields(xblogger.Fields{"daemon": daemon.String()})
+	if err := daemon.process.Start(xblogger.IntoContext(ctx, logger)); err == nil {
+		logger.Info("Daemon succeeded in exiting process.")
 	} else {
-		xblogger.WithFields(fields).WithError(err).Error("Daemon failed to exit process.")
+		logger.WithError(err).Error("Daemon failed to exit process.")
 	}
 }
 
 func (daemon *Daemon) stamp() {
+	daemonGauge.Dec(daemon.typeName)
 	daemon.isActive = false
 }
 
@@ -285,6 +390,8 @@ func (daemon *Daemon) String() string {
 	return fmt.Sprintf("<Daemon| typeName: `%s`, isActive: `%v`>", daemon.typeName, daemon.isActive)
 }
 
+// Process is started with the root context, which carries the daemon logger
+// for `xblogger.FromContext`.
 type Process interface {
 	Setup() error
 	Start(ctx context.Context) error
@@ -318,3 +425,64 @@ func (process *ServerProcess) Start(ctx context.Context) error {
 func (process *ServerProcess) SetServer(server *http.Server) {
 	process.server = server
 }
+
+const defaultWatchInterval = 5 * time.Second
+
+// WatchProcess polls the modification time of a file and performs the reload
+// whenever it changes, e.g. to hot reload a config file.
+type WatchProcess struct {
+	path     string
+	interval time.Duration
+	reload   Reload
+	modTime  time.Time
+}
+
+func (process *WatchProcess) Setup() error {
+	if process.reload == nil {
+		panic("Watch process requires a reload operation.")
+	}
+	if process.interval <= 0 {
+		process.interval = defaultWatchInterval
+	}
+	info, err := os.Stat(process.path)
+	if err != nil {
+		return err
+	}
+	process.modTime = info.ModTime()
+	return nil
+}
+
+func (process *WatchProcess) Start(ctx context.Context) error {
+	logger := xblogger.FromContext(ctx).WithField("path", process.path)
+	ticker := time.NewTicker(process.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			info, err := os.Stat(process.path)
+			if err != nil || !info.ModTime().After(process.modTime) {
+				continue
+			}
+			process.modTime = info.ModTime()
+			if err := process.reload(); err != nil {
+				logger.WithError(err).Error("Watch process failed to reload.")
+			} else {
+				logger.Info("Watch process reloaded.")
+			}
+		}
+	}
+}
+
+func (process *WatchProcess) SetPath(path string) {
+	process.path = path
+}
+
+func (process *WatchProcess) SetInterval(interval time.Duration) {
+	process.interval = interval
+}
+
+func (process *WatchProcess) SetReload(reload Reload) {
+	process.reload = reload
+}